@@ -54,6 +54,7 @@ generates a skeletal app-operator application in $GOPATH/src/github.com/example.
 	newCmd.Flags().StringVar(&kind, "kind", "", "Kubernetes CustomResourceDefintion kind. (e.g AppService)")
 	newCmd.MarkFlagRequired("kind")
 	newCmd.Flags().BoolVar(&skipGit, "skip-git-init", false, "Do not init the directory as a git repository")
+	newCmd.Flags().BoolVar(&statusSubresource, "status-subresource", false, "Enable the status subresource in the generated CRD. Requires Kubernetes 1.10+ with the CustomResourceSubresources feature gate")
 
 	return newCmd
 }
@@ -63,6 +64,8 @@ var (
 	kind        string
 	projectName string
 	skipGit     bool
+
+	statusSubresource bool
 )
 
 const (
@@ -79,7 +82,7 @@ func newFunc(cmd *cobra.Command, args []string) {
 	parse(args)
 	mustBeNewProject()
 	verifyFlags()
-	g := generator.NewGenerator(apiVersion, kind, projectName, repoPath())
+	g := generator.NewGenerator(apiVersion, kind, projectName, repoPath(), statusSubresource)
 	err := g.Render()
 	if err != nil {
 		cmdError.ExitWithError(cmdError.ExitError, fmt.Errorf("failed to create project %v: %v", projectName, err))
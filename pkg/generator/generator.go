@@ -65,6 +65,7 @@ const (
 	rbacYaml           = "rbac.yaml"
 	crYaml             = "cr.yaml"
 	saYaml             = "sa.yaml"
+	serviceMonitorYaml = "service-monitor.yaml"
 	catalogPackageYaml = "package.yaml"
 	catalogCSVYaml     = "csv.yaml"
 	crdYaml            = "crd.yaml"
@@ -83,6 +84,7 @@ const (
 	crTmplName         = "deploy/cr.yaml"
 	testYamlName       = "deploy/test-pod.yaml"
 	saTmplName         = "deploy/sa.yaml"
+	serviceMonitorName = "deploy/service-monitor.yaml"
 	pluralSuffix       = "s"
 )
 
@@ -95,11 +97,14 @@ type Generator struct {
 	projectName string
 	// repoPath is the project's repository path rooted under $GOPATH.
 	repoPath string
+	// statusSubresource enables the status subresource in the generated CRD.
+	statusSubresource bool
 }
 
 // NewGenerator creates a new scaffold Generator.
-func NewGenerator(apiVersion, kind, projectName, repoPath string) *Generator {
-	return &Generator{apiVersion: apiVersion, kind: kind, projectName: projectName, repoPath: repoPath}
+// statusSubresource enables the status subresource in the generated CRD, see sdk.UpdateStatus.
+func NewGenerator(apiVersion, kind, projectName, repoPath string, statusSubresource bool) *Generator {
+	return &Generator{apiVersion: apiVersion, kind: kind, projectName: projectName, repoPath: repoPath, statusSubresource: statusSubresource}
 }
 
 // Render generates the default project structure:
@@ -203,7 +208,7 @@ func renderConfigFiles(configDir, apiVersion, kind, projectName string) error {
 
 func (g *Generator) renderDeploy() error {
 	dp := filepath.Join(g.projectName, deployDir)
-	return renderDeployFiles(dp, g.projectName, g.apiVersion, g.kind)
+	return renderDeployFiles(dp, g.projectName, g.apiVersion, g.kind, g.statusSubresource)
 }
 
 func renderRBAC(deployDir, projectName, groupName string) error {
@@ -215,7 +220,7 @@ func renderRBAC(deployDir, projectName, groupName string) error {
 	return renderWriteFile(filepath.Join(deployDir, rbacYaml), rbacTmplName, rbacYamlTmpl, td)
 }
 
-func renderDeployFiles(deployDir, projectName, apiVersion, kind string) error {
+func renderDeployFiles(deployDir, projectName, apiVersion, kind string, statusSubresource bool) error {
 	rbacTd := tmplData{
 		ProjectName: projectName,
 		GroupName:   groupName(apiVersion),
@@ -230,6 +235,8 @@ func renderDeployFiles(deployDir, projectName, apiVersion, kind string) error {
 		KindPlural:   toPlural(strings.ToLower(kind)),
 		GroupName:    groupName(apiVersion),
 		Version:      version(apiVersion),
+
+		StatusSubresource: statusSubresource,
 	}
 	if err := renderWriteFile(filepath.Join(deployDir, crdYaml), crdTmplName, crdYamlTmpl, crdTd); err != nil {
 		return err
@@ -255,9 +262,20 @@ func renderDeployFiles(deployDir, projectName, apiVersion, kind string) error {
 		Image:           "REPLACE_IMAGE",
 		MetricsPort:     k8sutil.PrometheusMetricsPort,
 		MetricsPortName: k8sutil.PrometheusMetricsPortName,
+		HealthPort:      k8sutil.HealthProbePort,
+		HealthPortName:  k8sutil.HealthProbePortName,
 		OperatorNameEnv: k8sutil.OperatorNameEnvVar,
 	}
-	return renderWriteFile(filepath.Join(deployDir, "operator.yaml"), operatorTmplName, operatorYamlTmpl, opTd)
+	if err := renderWriteFile(filepath.Join(deployDir, "operator.yaml"), operatorTmplName, operatorYamlTmpl, opTd); err != nil {
+		return err
+	}
+
+	smTd := tmplData{
+		ProjectName:     projectName,
+		MetricsPortName: k8sutil.PrometheusMetricsPortName,
+		MetricsPath:     k8sutil.PrometheusMetricsPath,
+	}
+	return renderWriteFile(filepath.Join(deployDir, serviceMonitorYaml), serviceMonitorName, serviceMonitorYamlTmpl, smTd)
 }
 
 func RenderTestYaml(c *Config, image string) error {
@@ -465,11 +483,16 @@ type tmplData struct {
 	KindSingular string
 	// plural name to be used in the URL: /apis/<group>/<version>/<plural>
 	KindPlural string
+	// enables the status subresource of the CRD
+	StatusSubresource bool
 
 	Image           string
 	Name            string
 	MetricsPort     int
 	MetricsPortName string
+	MetricsPath     string
+	HealthPort      int
+	HealthPortName  string
 	OperatorNameEnv string
 
 	PackageName string
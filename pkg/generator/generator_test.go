@@ -185,12 +185,32 @@ spec:
   version: v1alpha1
 `
 
+const crdYamlStatusExp = `apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: appservices.app.example.com
+spec:
+  group: app.example.com
+  names:
+    kind: AppService
+    listKind: AppServiceList
+    plural: appservices
+    singular: appservice
+  scope: Namespaced
+  version: v1alpha1
+  subresources:
+    status: {}
+`
+
 const operatorYamlExp = `apiVersion: apps/v1
 kind: Deployment
 metadata:
   name: app-operator
 spec:
   replicas: 1
+  # The new pod can only become ready once the old pod released the leader lock.
+  strategy:
+    type: Recreate
   selector:
     matchLabels:
       name: app-operator
@@ -206,18 +226,51 @@ spec:
           ports:
           - containerPort: 60000
             name: metrics
+          - containerPort: 60001
+            name: health
           command:
           - app-operator
           imagePullPolicy: Always
+          livenessProbe:
+            httpGet:
+              path: /healthz
+              port: health
+            initialDelaySeconds: 15
+            periodSeconds: 20
+          readinessProbe:
+            httpGet:
+              path: /readyz
+              port: health
+            initialDelaySeconds: 5
+            periodSeconds: 10
           env:
             - name: WATCH_NAMESPACE
               valueFrom:
                 fieldRef:
                   fieldPath: metadata.namespace
+            - name: POD_NAME
+              valueFrom:
+                fieldRef:
+                  fieldPath: metadata.name
             - name: OPERATOR_NAME
               value: "app-operator"
 `
 
+const serviceMonitorYamlExp = `apiVersion: monitoring.coreos.com/v1
+kind: ServiceMonitor
+metadata:
+  name: app-operator
+  labels:
+    name: app-operator
+spec:
+  selector:
+    matchLabels:
+      name: app-operator
+  endpoints:
+  - port: metrics
+    path: /metrics
+`
+
 const rbacYamlExp = `kind: Role
 apiVersion: rbac.authorization.k8s.io/v1beta1
 metadata:
@@ -290,12 +343,25 @@ func TestGenDeploy(t *testing.T) {
 		t.Errorf("\nTest failed. Below is the diff of the expected vs actual results.\nRed text is missing and green text is extra.\n\n" + dmp.DiffPrettyText(diffs))
 	}
 
+	buf = &bytes.Buffer{}
+	crdTd.StatusSubresource = true
+	if err := renderFile(buf, crdTmplName, crdYamlTmpl, crdTd); err != nil {
+		t.Error(err)
+	}
+	if crdYamlStatusExp != buf.String() {
+		dmp := diffmatchpatch.New()
+		diffs := dmp.DiffMain(crdYamlStatusExp, buf.String(), false)
+		t.Errorf("\nTest failed. Below is the diff of the expected vs actual results.\nRed text is missing and green text is extra.\n\n" + dmp.DiffPrettyText(diffs))
+	}
+
 	buf = &bytes.Buffer{}
 	td := tmplData{
 		ProjectName:     appProjectName,
 		Image:           appImage,
 		MetricsPort:     k8sutil.PrometheusMetricsPort,
 		MetricsPortName: k8sutil.PrometheusMetricsPortName,
+		HealthPort:      k8sutil.HealthProbePort,
+		HealthPortName:  k8sutil.HealthProbePortName,
 		OperatorNameEnv: k8sutil.OperatorNameEnvVar,
 	}
 	if err := renderFile(buf, operatorTmplName, operatorYamlTmpl, td); err != nil {
@@ -317,6 +383,21 @@ func TestGenDeploy(t *testing.T) {
 		t.Errorf("\nTest failed. Below is the diff of the expected vs actual results.\nRed text is missing and green text is extra.\n\n" + dmp.DiffPrettyText(diffs))
 	}
 
+	buf = &bytes.Buffer{}
+	smTd := tmplData{
+		ProjectName:     appProjectName,
+		MetricsPortName: k8sutil.PrometheusMetricsPortName,
+		MetricsPath:     k8sutil.PrometheusMetricsPath,
+	}
+	if err := renderFile(buf, serviceMonitorName, serviceMonitorYamlTmpl, smTd); err != nil {
+		t.Error(err)
+	}
+	if serviceMonitorYamlExp != buf.String() {
+		dmp := diffmatchpatch.New()
+		diffs := dmp.DiffMain(serviceMonitorYamlExp, buf.String(), false)
+		t.Errorf("\nTest failed. Below is the diff of the expected vs actual results.\nRed text is missing and green text is extra.\n\n" + dmp.DiffPrettyText(diffs))
+	}
+
 	buf = &bytes.Buffer{}
 	if err := renderFile(buf, saTmplName, saYamlTmpl, tmplData{ProjectName: appProjectName}); err != nil {
 		t.Error(err)
@@ -469,7 +550,7 @@ func main() {
 	logrus.Infof("Watching %s, %s, %s, %d", resource, kind, namespace, resyncPeriod)
 	sdk.Watch(resource, kind, namespace, resyncPeriod)
 	sdk.Handle(stub.NewHandler())
-	sdk.Run(context.TODO())
+	sdk.Run(context.TODO(), sdk.WithLeaderElection())
 }
 `
 
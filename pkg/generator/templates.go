@@ -164,7 +164,7 @@ func main() {
 	logrus.Infof("Watching %s, %s, %s, %d", resource, kind, namespace, resyncPeriod)
 	sdk.Watch(resource, kind, namespace, resyncPeriod)
 	sdk.Handle(stub.NewHandler())
-	sdk.Run(context.TODO())
+	sdk.Run(context.TODO(), sdk.WithLeaderElection())
 }
 `
 
@@ -422,6 +422,10 @@ spec:
     singular: {{.KindSingular}}
   scope: Namespaced
   version: {{.Version}}
+{{- if .StatusSubresource}}
+  subresources:
+    status: {}
+{{- end}}
 `
 
 const testYamlTmpl = `apiVersion: v1
@@ -448,6 +452,9 @@ metadata:
   name: {{.ProjectName}}
 spec:
   replicas: 1
+  # The new pod can only become ready once the old pod released the leader lock.
+  strategy:
+    type: Recreate
   selector:
     matchLabels:
       name: {{.ProjectName}}
@@ -463,14 +470,32 @@ spec:
           ports:
           - containerPort: {{.MetricsPort}}
             name: {{.MetricsPortName}}
+          - containerPort: {{.HealthPort}}
+            name: {{.HealthPortName}}
           command:
           - {{.ProjectName}}
           imagePullPolicy: Always
+          livenessProbe:
+            httpGet:
+              path: /healthz
+              port: {{.HealthPortName}}
+            initialDelaySeconds: 15
+            periodSeconds: 20
+          readinessProbe:
+            httpGet:
+              path: /readyz
+              port: {{.HealthPortName}}
+            initialDelaySeconds: 5
+            periodSeconds: 10
           env:
             - name: WATCH_NAMESPACE
               valueFrom:
                 fieldRef:
                   fieldPath: metadata.namespace
+            - name: POD_NAME
+              valueFrom:
+                fieldRef:
+                  fieldPath: metadata.name
             - name: {{.OperatorNameEnv}}
               value: "{{.ProjectName}}"
 `
@@ -529,6 +554,23 @@ metadata:
   name: {{.ProjectName}}
 `
 
+// serviceMonitorYamlTmpl is the template for deploy/service-monitor.yaml, which makes
+// the Prometheus operator scrape the metrics service created by sdk.ExposeMetricsPort.
+const serviceMonitorYamlTmpl = `apiVersion: monitoring.coreos.com/v1
+kind: ServiceMonitor
+metadata:
+  name: {{.ProjectName}}
+  labels:
+    name: {{.ProjectName}}
+spec:
+  selector:
+    matchLabels:
+      name: {{.ProjectName}}
+  endpoints:
+  - port: {{.MetricsPortName}}
+    path: {{.MetricsPath}}
+`
+
 const crYamlTmpl = `apiVersion: "{{.APIVersion}}"
 kind: "{{.Kind}}"
 metadata:
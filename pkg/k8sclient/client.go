@@ -15,77 +15,236 @@
 package k8sclient
 
 import (
+	"context"
 	"fmt"
 	"net"
 	"os"
 	"sync"
-	"time"
 
 	"github.com/operator-framework/operator-sdk/pkg/util/k8sutil"
 
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime/schema"
-	"k8s.io/client-go/discovery/cached"
+	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/rest"
-	"k8s.io/client-go/restmapper"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/flowcontrol"
 )
 
-type resourceClientFactory struct {
+// Factory creates the clients for a Kubernetes cluster.
+type Factory struct {
 	dynamicClient dynamic.Interface
-	restMapper    *restmapper.DeferredDiscoveryRESTMapper
-	kubeClient    kubernetes.Interface
-	kubeConfig    *rest.Config
+	// restClient sends the requests of the resource clients bound to a context,
+	// with the connections and the rate limiter of dynamicClient.
+	restClient *rest.RESTClient
+	restMapper *lazyRESTMapper
+	kubeClient kubernetes.Interface
+	kubeConfig *rest.Config
+
+	// broadcaster sends the recorded events to the cluster, see EventBroadcaster.
+	// sink stops sending them, and stopped is set once the factory is stopped.
+	broadcasterMu sync.Mutex
+	broadcaster   record.EventBroadcaster
+	sink          watch.Interface
+	stopped       bool
 }
 
 var (
-	// this stores the singleton in a package local
-	singletonFactory *resourceClientFactory
-	once             sync.Once
+	// defaultFactory is the Factory used by the package-level functions.
+	defaultFactory   *Factory
+	defaultFactoryMu sync.Mutex
 )
 
-// Private constructor for once.Do
-func newSingletonFactory() {
-	kubeClient, kubeConfig := mustNewKubeClientAndConfig()
-	cachedDiscoveryClient := cached.NewMemCacheClient(kubeClient.Discovery())
-	restMapper := restmapper.NewDeferredDiscoveryRESTMapper(cachedDiscoveryClient)
-	restMapper.Reset()
+// NewFactory creates a Factory for the cluster the operator runs in or, if KUBERNETES_CONFIG
+// is set, for the cluster of the current context of that kubeconfig file.
+// "opts" configures the clients, e.g their rate limit.
+// Returns an error if the config can't be loaded or the clients can't be created.
+func NewFactory(opts ...FactoryOption) (*Factory, error) {
+	o := newFactoryOp()
+	o.applyOpts(opts)
+	kubeConfig, err := loadConfig(o)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kubernetes config: %v", err)
+	}
+	return newFactory(kubeConfig, o)
+}
+
+// NewFactoryForConfig creates a Factory for the cluster of the given config, e.g for another
+// cluster than the one the operator runs in. "opts" configures the clients, but WithKubeConfigContext
+// has no effect. Returns an error if the clients can't be created.
+func NewFactoryForConfig(config *rest.Config, opts ...FactoryOption) (*Factory, error) {
+	o := newFactoryOp()
+	o.applyOpts(opts)
+	return newFactory(rest.CopyConfig(config), o)
+}
 
-	dynamicClient, err := dynamic.NewForConfig(kubeConfig)
+// NewFactoryForKubeConfig creates a Factory for the cluster of the current context of the given
+// kubeconfig file contents, or of the context set by WithKubeConfigContext.
+// Returns an error if the kubeconfig is invalid or the clients can't be created.
+func NewFactoryForKubeConfig(kubeConfig []byte, opts ...FactoryOption) (*Factory, error) {
+	o := newFactoryOp()
+	o.applyOpts(opts)
+	c, err := clientcmd.Load(kubeConfig)
 	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("failed to parse kubeconfig: %v", err)
+	}
+	config, err := clientcmd.NewNonInteractiveClientConfig(*c, o.kubeConfigContext, &clientcmd.ConfigOverrides{}, nil).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kubeconfig: %v", err)
+	}
+	return newFactory(config, o)
+}
+
+// newFactory creates a Factory for the config, which it configures with the options.
+func newFactory(kubeConfig *rest.Config, o *factoryOp) (*Factory, error) {
+	o.applyToConfig(kubeConfig)
+	kubeClient, err := kubernetes.NewForConfig(kubeConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kubernetes client: %v", err)
+	}
+	dynamicConfig := rest.CopyConfig(kubeConfig)
+	if dynamicConfig.RateLimiter == nil {
+		qps, burst := dynamicConfig.QPS, dynamicConfig.Burst
+		if qps == 0 {
+			qps, burst = rest.DefaultQPS, rest.DefaultBurst
+		}
+		dynamicConfig.RateLimiter = flowcontrol.NewTokenBucketRateLimiter(qps, burst)
+	}
+	dynamicClient, err := dynamic.NewForConfig(dynamicConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dynamic client: %v", err)
+	}
+	restClient, err := newContextRESTClient(dynamicConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create REST client: %v", err)
 	}
 
-	singletonFactory = &resourceClientFactory{
+	return &Factory{
 		kubeClient:    kubeClient,
 		kubeConfig:    kubeConfig,
 		dynamicClient: dynamicClient,
-		restMapper:    restMapper,
+		restClient:    restClient,
+		restMapper:    newLazyRESTMapper(kubeClient.Discovery(), o.minRefreshInterval),
+	}, nil
+}
+
+// Stop stops refreshing the discovery information of the factory, e.g when the factory
+// is no longer used. The clients of a stopped factory keep working, but only for the
+// kinds that were discovered before it was stopped. The events recorded with the
+// recorders of its EventBroadcaster are no longer sent.
+func (f *Factory) Stop() {
+	f.restMapper.stop()
+	f.broadcasterMu.Lock()
+	defer f.broadcasterMu.Unlock()
+	f.stopped = true
+	if f.sink != nil {
+		f.sink.Stop()
 	}
-	singletonFactory.runBackgroundCacheReset(1 * time.Minute)
 }
 
-// GetResourceClient returns the resource client using a singleton factory
+// EventBroadcaster returns the broadcaster that sends the events recorded with its recorders
+// to the cluster of the factory. It is started on first use.
+func (f *Factory) EventBroadcaster() record.EventBroadcaster {
+	f.broadcasterMu.Lock()
+	defer f.broadcasterMu.Unlock()
+	if f.broadcaster == nil {
+		f.broadcaster = record.NewBroadcaster()
+		if !f.stopped {
+			f.sink = f.broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: f.kubeClient.CoreV1().Events("")})
+		}
+	}
+	return f.broadcaster
+}
+
+// SetDefaultFactory sets the Factory used by the package-level functions,
+// e.g to configure its clients with options. It must be called before the
+// first client is used, e.g at the start of main.
+func SetDefaultFactory(f *Factory) {
+	defaultFactoryMu.Lock()
+	defer defaultFactoryMu.Unlock()
+	defaultFactory = f
+}
+
+// DefaultFactory returns the Factory used by the package-level functions.
+// Unless one was set by SetDefaultFactory, it is created on first use with NewFactory().
+// Creating it is retried on the next call if it fails.
+func DefaultFactory() (*Factory, error) {
+	defaultFactoryMu.Lock()
+	defer defaultFactoryMu.Unlock()
+	if defaultFactory == nil {
+		f, err := NewFactory()
+		if err != nil {
+			return nil, err
+		}
+		defaultFactory = f
+	}
+	return defaultFactory, nil
+}
+
+// mustDefaultFactory returns the default Factory, and panics if it can't be created.
+func mustDefaultFactory() *Factory {
+	f, err := DefaultFactory()
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
+
+// GetResourceClient returns the resource client using the default factory
 func GetResourceClient(apiVersion, kind, namespace string) (dynamic.ResourceInterface, string, error) {
-	once.Do(newSingletonFactory)
-	return singletonFactory.GetResourceClient(apiVersion, kind, namespace)
+	f, err := DefaultFactory()
+	if err != nil {
+		return nil, "", err
+	}
+	return f.GetResourceClient(apiVersion, kind, namespace)
+}
+
+// GetResourceClientWithContext is like GetResourceClient, but the requests of the returned
+// client are bound to ctx: they are canceled once ctx is done, e.g when its deadline expires.
+func GetResourceClientWithContext(ctx context.Context, apiVersion, kind, namespace string) (dynamic.ResourceInterface, string, error) {
+	f, err := DefaultFactory()
+	if err != nil {
+		return nil, "", err
+	}
+	return f.GetResourceClientWithContext(ctx, apiVersion, kind, namespace)
 }
 
 // GetKubeClient returns the kubernetes client used to create the dynamic client
+// Panics if the default factory can't be created, see DefaultFactory.
 func GetKubeClient() kubernetes.Interface {
-	once.Do(newSingletonFactory)
-	return singletonFactory.kubeClient
+	return mustDefaultFactory().kubeClient
 }
 
 // GetKubeConfig returns the kubernetes rest configuration
+// Panics if the default factory can't be created, see DefaultFactory.
 func GetKubeConfig() *rest.Config {
-	once.Do(newSingletonFactory)
-	return singletonFactory.kubeConfig
+	return mustDefaultFactory().kubeConfig
+}
+
+// KubeClient returns the kubernetes client used to create the dynamic client
+func (f *Factory) KubeClient() kubernetes.Interface {
+	return f.kubeClient
+}
+
+// KubeConfig returns the kubernetes rest configuration
+func (f *Factory) KubeConfig() *rest.Config {
+	return f.kubeConfig
 }
 
 // GetResourceClient returns the dynamic client and pluralName for the resource specified by the apiVersion and kind
-func (c *resourceClientFactory) GetResourceClient(apiVersion, kind, namespace string) (dynamic.ResourceInterface, string, error) {
+func (f *Factory) GetResourceClient(apiVersion, kind, namespace string) (dynamic.ResourceInterface, string, error) {
+	return f.GetResourceClientWithContext(context.Background(), apiVersion, kind, namespace)
+}
+
+// GetResourceClientWithContext returns the dynamic client and pluralName for the resource specified
+// by the apiVersion and kind. The requests of the client are bound to ctx.
+// If the kind is not known to the API server, e.g because its CRD is not installed yet,
+// the error satisfies meta.IsNoMatchError.
+func (f *Factory) GetResourceClientWithContext(ctx context.Context, apiVersion, kind, namespace string) (dynamic.ResourceInterface, string, error) {
 	gv, err := schema.ParseGroupVersion(apiVersion)
 	if err != nil {
 		return nil, "", fmt.Errorf("failed to parse apiVersion: %v", err)
@@ -96,39 +255,42 @@ func (c *resourceClientFactory) GetResourceClient(apiVersion, kind, namespace st
 		Kind:    kind,
 	}
 
-	gvr, err := gvkToGVR(gvk, c.restMapper)
+	gvr, err := gvkToGVR(gvk, f.restMapper)
+	if meta.IsNoMatchError(err) {
+		return nil, "", err
+	}
 	if err != nil {
 		return nil, "", fmt.Errorf("failed to get resource type: %v", err)
 	}
 	pluralName := gvr.Resource
 
-	resourceClient := c.dynamicClient.Resource(*gvr).Namespace(namespace)
+	// The requests of a context that is never done need no binding.
+	if ctx.Done() == nil {
+		return f.dynamicClient.Resource(*gvr).Namespace(namespace), pluralName, nil
+	}
+	resourceClient := &contextResourceClient{ctx: ctx, client: f.restClient, resource: *gvr, namespace: namespace}
 	return resourceClient, pluralName, nil
 }
 
-// apiResource consults the REST mapper to translate an <apiVersion, kind, namespace> tuple to a GroupVersionResource
-func gvkToGVR(gvk schema.GroupVersionKind, restMapper *restmapper.DeferredDiscoveryRESTMapper) (*schema.GroupVersionResource, error) {
+// gvkToGVR consults the REST mapper to translate an <apiVersion, kind, namespace> tuple to a GroupVersionResource
+func gvkToGVR(gvk schema.GroupVersionKind, restMapper *lazyRESTMapper) (*schema.GroupVersionResource, error) {
 	mapping, err := restMapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if meta.IsNoMatchError(err) {
+		return nil, err
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get the resource REST mapping for GroupVersionKind(%s): %v", gvk.String(), err)
 	}
 	return &mapping.Resource, nil
 }
 
-// mustNewKubeClientAndConfig returns the in-cluster config and kubernetes client
-// or if KUBERNETES_CONFIG is given an out of cluster config and client
-func mustNewKubeClientAndConfig() (kubernetes.Interface, *rest.Config) {
-	var cfg *rest.Config
-	var err error
-	if os.Getenv(k8sutil.KubeConfigEnvVar) != "" {
-		cfg, err = outOfClusterConfig()
-	} else {
-		cfg, err = inClusterConfig()
-	}
-	if err != nil {
-		panic(err)
+// loadConfig returns the in-cluster config or, if KUBERNETES_CONFIG is given or
+// a kubeconfig context is set, an out of cluster config.
+func loadConfig(o *factoryOp) (*rest.Config, error) {
+	if os.Getenv(k8sutil.KubeConfigEnvVar) != "" || o.kubeConfigContext != "" {
+		return outOfClusterConfig(o.kubeConfigContext)
 	}
-	return kubernetes.NewForConfigOrDie(cfg), cfg
+	return inClusterConfig()
 }
 
 // inClusterConfig returns the in-cluster config accessible inside a pod
@@ -148,19 +310,12 @@ func inClusterConfig() (*rest.Config, error) {
 	return rest.InClusterConfig()
 }
 
-func outOfClusterConfig() (*rest.Config, error) {
-	kubeconfig := os.Getenv(k8sutil.KubeConfigEnvVar)
-	config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
-	return config, err
-}
-
-// runBackgroundCacheReset - Starts the rest mapper cache reseting
-// at a duration given.
-func (c *resourceClientFactory) runBackgroundCacheReset(duration time.Duration) {
-	ticker := time.NewTicker(duration)
-	go func() {
-		for range ticker.C {
-			c.restMapper.Reset()
-		}
-	}()
+// outOfClusterConfig returns the config for the given context of the kubeconfig file in
+// KUBERNETES_CONFIG, or of the default kubeconfig files if it is not set.
+// The current context is used if kubeConfigContext is empty.
+func outOfClusterConfig(kubeConfigContext string) (*rest.Config, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = os.Getenv(k8sutil.KubeConfigEnvVar)
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: kubeConfigContext}
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides).ClientConfig()
 }
@@ -0,0 +1,157 @@
+// Copyright 2018 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8sclient
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/operator-framework/operator-sdk/pkg/util/k8sutil"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+const testKubeConfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: dev
+  cluster:
+    server: https://dev.example.com
+- name: prod
+  cluster:
+    server: https://prod.example.com
+users:
+- name: admin
+  user:
+    token: secret
+contexts:
+- name: dev
+  context:
+    cluster: dev
+    user: admin
+- name: prod
+  context:
+    cluster: prod
+    user: admin
+current-context: dev
+`
+
+func TestLoadConfig(t *testing.T) {
+	f, err := ioutil.TempFile("", "kubeconfig")
+	if err != nil {
+		t.Fatalf("failed to create kubeconfig: %v", err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString(testKubeConfig); err != nil {
+		t.Fatalf("failed to write kubeconfig: %v", err)
+	}
+	f.Close()
+	os.Setenv(k8sutil.KubeConfigEnvVar, f.Name())
+	defer os.Unsetenv(k8sutil.KubeConfigEnvVar)
+
+	type Scenario struct {
+		name            string
+		opts            []FactoryOption
+		expectedHost    string
+		expectedQPS     float32
+		expectedUser    string
+		expectedTimeout time.Duration
+		expectError     bool
+	}
+
+	tests := []Scenario{
+		Scenario{
+			name:         "Current context",
+			expectedHost: "https://dev.example.com",
+		},
+		Scenario{
+			name:            "Other context with options",
+			opts:            []FactoryOption{WithKubeConfigContext("prod"), WithQPS(50), WithImpersonation("jane"), WithTimeout(time.Minute)},
+			expectedHost:    "https://prod.example.com",
+			expectedQPS:     50,
+			expectedUser:    "jane",
+			expectedTimeout: time.Minute,
+		},
+		Scenario{
+			name:        "Missing context",
+			opts:        []FactoryOption{WithKubeConfigContext("staging")},
+			expectError: true,
+		},
+	}
+
+	for _, test := range tests {
+		o := newFactoryOp()
+		o.applyOpts(test.opts)
+		config, err := loadConfig(o)
+		if test.expectError {
+			if err == nil {
+				t.Errorf("test %s failed, expected an error", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("test %s failed: %v", test.name, err)
+			continue
+		}
+		o.applyToConfig(config)
+		if config.Host != test.expectedHost {
+			t.Errorf("test %s failed, expected host: %s; got: %s", test.name, test.expectedHost, config.Host)
+		}
+		if config.QPS != test.expectedQPS {
+			t.Errorf("test %s failed, expected QPS: %v; got: %v", test.name, test.expectedQPS, config.QPS)
+		}
+		if config.Impersonate.UserName != test.expectedUser {
+			t.Errorf("test %s failed, expected impersonated user: %s; got: %s", test.name, test.expectedUser, config.Impersonate.UserName)
+		}
+		if config.Timeout != test.expectedTimeout {
+			t.Errorf("test %s failed, expected timeout: %v; got: %v", test.name, test.expectedTimeout, config.Timeout)
+		}
+	}
+}
+
+func TestFactoryEventBroadcaster(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	f := &Factory{kubeClient: client, restMapper: newLazyRESTMapper(client.Discovery(), defaultMinRefreshInterval)}
+	recorder := f.EventBroadcaster().NewRecorder(scheme.Scheme, corev1.EventSource{Component: "app-operator"})
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "ns1"}}
+
+	countEvents := func() int {
+		events, err := client.CoreV1().Events("ns1").List(metav1.ListOptions{})
+		if err != nil {
+			t.Fatalf("failed to list events: %v", err)
+		}
+		return len(events.Items)
+	}
+	recorder.Event(pod, corev1.EventTypeWarning, "ReconcileFailed", "boom")
+	for n := 0; countEvents() == 0 && n < 100; n++ {
+		time.Sleep(50 * time.Millisecond)
+	}
+	if n := countEvents(); n != 1 {
+		t.Fatalf("expected the event to be sent to the cluster of the factory; got: %d events", n)
+	}
+
+	// A stopped factory no longer sends events.
+	f.Stop()
+	recorder.Event(pod, corev1.EventTypeNormal, "Scaled", "done")
+	time.Sleep(100 * time.Millisecond)
+	if n := countEvents(); n != 1 {
+		t.Errorf("expected no event to be sent by a stopped factory; got: %d events", n)
+	}
+}
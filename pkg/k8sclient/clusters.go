@@ -0,0 +1,189 @@
+// Copyright 2018 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8sclient
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// KubeConfigSecretKey is the key of the kubeconfig file in the data of a Secret,
+// see SetClusterFactoryFromSecret.
+const KubeConfigSecretKey = "kubeconfig"
+
+// ClusterNotFoundError is returned when no Factory is set for a cluster name.
+type ClusterNotFoundError struct {
+	Name string
+}
+
+func (e *ClusterNotFoundError) Error() string {
+	return fmt.Sprintf("no client factory set for cluster (%s)", e.Name)
+}
+
+// IsClusterNotFoundError returns true if the error indicates that no Factory is set for a cluster.
+func IsClusterNotFoundError(err error) bool {
+	_, ok := err.(*ClusterNotFoundError)
+	return ok
+}
+
+// cluster is a Factory set for a cluster name.
+type cluster struct {
+	factory *Factory
+	// secretUID and secretResourceVersion identify the Secret that the factory
+	// was created from, if any, to tell if the kubeconfig changed.
+	secretUID             types.UID
+	secretResourceVersion string
+	// lastUsed is the last time the factory was returned, see EvictIdleClusterFactories.
+	lastUsed time.Time
+}
+
+var (
+	// clusters are the factories set for cluster names. They are kept until they are replaced,
+	// removed with RemoveClusterFactory or evicted with EvictIdleClusterFactories.
+	clusters   = map[string]*cluster{}
+	clustersMu sync.RWMutex
+)
+
+// ClusterFactory returns the Factory set for the cluster of the given name, e.g by
+// SetClusterFactory. The empty name is the cluster of the default Factory, see DefaultFactory.
+// Returns a *ClusterNotFoundError if no Factory is set for the name.
+func ClusterFactory(name string) (*Factory, error) {
+	if name == "" {
+		return DefaultFactory()
+	}
+	clustersMu.Lock()
+	defer clustersMu.Unlock()
+	c, ok := clusters[name]
+	if !ok {
+		return nil, &ClusterNotFoundError{Name: name}
+	}
+	c.lastUsed = time.Now()
+	return c.factory, nil
+}
+
+// SetClusterFactory sets the Factory for the cluster of the given name, e.g created by
+// NewFactoryForConfig. A Factory already set for the name is stopped and replaced.
+// The caller removes the Factory of a cluster that is no longer managed with RemoveClusterFactory,
+// or evicts it once it is idle with EvictIdleClusterFactories.
+// The empty name sets the default Factory, see SetDefaultFactory.
+func SetClusterFactory(name string, f *Factory) {
+	if name == "" {
+		SetDefaultFactory(f)
+		return
+	}
+	clustersMu.Lock()
+	defer clustersMu.Unlock()
+	setCluster(name, &cluster{factory: f, lastUsed: time.Now()})
+}
+
+// SetClusterFactoryFromSecret sets the Factory for the cluster of the given name, created from
+// the kubeconfig file under KubeConfigSecretKey in the secret's data, and returns it.
+// The Factory is only created again if the secret changed since the Factory already set for
+// the name was created from it, so it can be called on each event for the cluster.
+// "opts" configures the clients of a newly created Factory. Like for SetClusterFactory,
+// the caller removes the Factory once the cluster is no longer managed, e.g when the secret is deleted.
+// Returns an error if the name is empty, the secret has no valid kubeconfig or the clients can't be created.
+func SetClusterFactoryFromSecret(name string, secret *corev1.Secret, opts ...FactoryOption) (*Factory, error) {
+	if name == "" {
+		return nil, fmt.Errorf("cluster name must not be empty")
+	}
+	// The lock is held until the factory is set, so that concurrent calls for the same
+	// secret share one factory instead of stopping each other's.
+	clustersMu.Lock()
+	defer clustersMu.Unlock()
+	c, ok := clusters[name]
+	if ok && c.secretUID == secret.UID && c.secretResourceVersion == secret.ResourceVersion {
+		c.lastUsed = time.Now()
+		return c.factory, nil
+	}
+
+	kubeConfig, ok := secret.Data[KubeConfigSecretKey]
+	if !ok {
+		return nil, fmt.Errorf("secret (%s/%s) has no %s key", secret.Namespace, secret.Name, KubeConfigSecretKey)
+	}
+	f, err := NewFactoryForKubeConfig(kubeConfig, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client factory from secret (%s/%s): %v", secret.Namespace, secret.Name, err)
+	}
+	setCluster(name, &cluster{factory: f, secretUID: secret.UID, secretResourceVersion: secret.ResourceVersion, lastUsed: time.Now()})
+	return f, nil
+}
+
+// setCluster sets the cluster for the name, and stops the factory it replaces.
+// clustersMu must be held.
+func setCluster(name string, c *cluster) {
+	old, ok := clusters[name]
+	clusters[name] = c
+	if ok && old.factory != c.factory {
+		old.factory.Stop()
+	}
+}
+
+// RemoveClusterFactory stops and removes the Factory set for the cluster of the given name,
+// e.g once the cluster is no longer managed by the operator.
+// Clients already created by the Factory keep working with the kinds they know.
+func RemoveClusterFactory(name string) {
+	clustersMu.Lock()
+	c, ok := clusters[name]
+	delete(clusters, name)
+	clustersMu.Unlock()
+	if ok {
+		c.factory.Stop()
+	}
+}
+
+// EvictIdleClusterFactories stops and removes the factories of the clusters that were not used
+// for longer than idleTimeout, and returns the sorted names of these clusters. A Factory is used
+// each time ClusterFactory or SetClusterFactoryFromSecret returns it, e.g for each request of an
+// sdk client for the cluster and each list and watch of an sdk watch of the cluster.
+// It is meant to be called periodically, e.g every minute with wait.Until. The Factory of an
+// evicted cluster is created again by the next SetClusterFactoryFromSecret call for the cluster,
+// while a Factory set with SetClusterFactory has to be set again.
+func EvictIdleClusterFactories(idleTimeout time.Duration) []string {
+	clustersMu.Lock()
+	var evicted []*cluster
+	names := []string{}
+	for name, c := range clusters {
+		if time.Since(c.lastUsed) > idleTimeout {
+			evicted = append(evicted, c)
+			names = append(names, name)
+			delete(clusters, name)
+		}
+	}
+	clustersMu.Unlock()
+	for _, c := range evicted {
+		c.factory.Stop()
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ClusterNames returns the sorted names of the clusters a Factory is set for,
+// e.g to remove the factories of clusters that are no longer managed.
+func ClusterNames() []string {
+	clustersMu.RLock()
+	defer clustersMu.RUnlock()
+	names := make([]string, 0, len(clusters))
+	for name := range clusters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,143 @@
+// Copyright 2018 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8sclient
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newKubeConfigSecret(resourceVersion string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "dev-kubeconfig", Namespace: "clusters", UID: "uid-1", ResourceVersion: resourceVersion},
+		Data:       map[string][]byte{KubeConfigSecretKey: []byte(testKubeConfig)},
+	}
+}
+
+func TestSetClusterFactoryFromSecret(t *testing.T) {
+	defer RemoveClusterFactory("dev")
+
+	f, err := SetClusterFactoryFromSecret("dev", newKubeConfigSecret("1"))
+	if err != nil {
+		t.Fatalf("failed to set cluster factory: %v", err)
+	}
+	if host := f.KubeConfig().Host; host != "https://dev.example.com" {
+		t.Errorf("expected host: https://dev.example.com; got: %s", host)
+	}
+	if got, err := ClusterFactory("dev"); err != nil || got != f {
+		t.Errorf("expected the factory set for the cluster; got: %v, %v", got, err)
+	}
+
+	// An unchanged secret reuses the factory.
+	if got, err := SetClusterFactoryFromSecret("dev", newKubeConfigSecret("1")); err != nil || got != f {
+		t.Errorf("expected the cached factory; got: %v, %v", got, err)
+	}
+
+	// A changed secret replaces and stops the factory.
+	updated, err := SetClusterFactoryFromSecret("dev", newKubeConfigSecret("2"), WithKubeConfigContext("prod"))
+	if err != nil {
+		t.Fatalf("failed to set cluster factory: %v", err)
+	}
+	if updated == f {
+		t.Errorf("expected a new factory for the changed secret")
+	}
+	if host := updated.KubeConfig().Host; host != "https://prod.example.com" {
+		t.Errorf("expected host: https://prod.example.com; got: %s", host)
+	}
+	if !f.restMapper.stopped {
+		t.Errorf("expected the replaced factory to be stopped")
+	}
+
+	if names := ClusterNames(); len(names) != 1 || names[0] != "dev" {
+		t.Errorf("expected cluster names: [dev]; got: %v", names)
+	}
+	RemoveClusterFactory("dev")
+	if !updated.restMapper.stopped {
+		t.Errorf("expected the removed factory to be stopped")
+	}
+	if _, err := ClusterFactory("dev"); !IsClusterNotFoundError(err) {
+		t.Errorf("expected cluster not found error; got: %v", err)
+	}
+}
+
+func TestSetClusterFactoryFromSecretConcurrently(t *testing.T) {
+	defer RemoveClusterFactory("dev")
+
+	// Concurrent calls for the same secret share one factory, which none of them stops.
+	factories := make(chan *Factory, 10)
+	var wg sync.WaitGroup
+	for n := 0; n < cap(factories); n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			f, err := SetClusterFactoryFromSecret("dev", newKubeConfigSecret("1"))
+			if err != nil {
+				t.Errorf("failed to set cluster factory: %v", err)
+			}
+			factories <- f
+		}()
+	}
+	wg.Wait()
+	close(factories)
+	first := <-factories
+	for f := range factories {
+		if f != first {
+			t.Errorf("expected all calls to return the same factory")
+		}
+	}
+	if first.restMapper.stopped {
+		t.Errorf("expected the shared factory not to be stopped")
+	}
+}
+
+func TestEvictIdleClusterFactories(t *testing.T) {
+	defer RemoveClusterFactory("dev")
+	defer RemoveClusterFactory("staging")
+
+	idle, err := NewFactoryForKubeConfig([]byte(testKubeConfig))
+	if err != nil {
+		t.Fatalf("failed to create factory: %v", err)
+	}
+	SetClusterFactory("staging", idle)
+	used, err := SetClusterFactoryFromSecret("dev", newKubeConfigSecret("1"))
+	if err != nil {
+		t.Fatalf("failed to set cluster factory: %v", err)
+	}
+	clustersMu.Lock()
+	clusters["staging"].lastUsed = time.Now().Add(-2 * time.Hour)
+	clusters["dev"].lastUsed = time.Now().Add(-2 * time.Hour)
+	clustersMu.Unlock()
+	// Getting the factory marks the cluster as used.
+	if _, err := ClusterFactory("dev"); err != nil {
+		t.Fatalf("failed to get cluster factory: %v", err)
+	}
+
+	if evicted := EvictIdleClusterFactories(time.Hour); len(evicted) != 1 || evicted[0] != "staging" {
+		t.Errorf("expected evicted clusters: [staging]; got: %v", evicted)
+	}
+	if !idle.restMapper.stopped {
+		t.Errorf("expected the evicted factory to be stopped")
+	}
+	if used.restMapper.stopped {
+		t.Errorf("expected the used factory not to be stopped")
+	}
+	if names := ClusterNames(); len(names) != 1 || names[0] != "dev" {
+		t.Errorf("expected cluster names: [dev]; got: %v", names)
+	}
+}
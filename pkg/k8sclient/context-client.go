@@ -0,0 +1,187 @@
+// Copyright 2018 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8sclient
+
+import (
+	"context"
+	"io"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/runtime/serializer/json"
+	"k8s.io/apimachinery/pkg/runtime/serializer/streaming"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+)
+
+// versionV1 is the version the options of the requests are encoded in.
+var versionV1 = schema.GroupVersion{Version: "v1"}
+
+// watchEventSerializer decodes the events of a watch, whose objects are then decoded as unstructured.
+var watchEventSerializer = json.NewSerializer(json.DefaultMetaFactory, scheme.Scheme, scheme.Scheme, false)
+
+// newContextRESTClient returns the REST client of the contextResourceClients of a factory.
+// It shares the connections and the rate limiter of the dynamic client created for the config.
+func newContextRESTClient(dynamicConfig *rest.Config) (*rest.RESTClient, error) {
+	config := rest.CopyConfig(dynamicConfig)
+	// The requests set their absolute path, the group version is only needed to create the client.
+	config.GroupVersion = &schema.GroupVersion{}
+	config.APIPath = "/"
+	config.AcceptContentTypes = "application/json"
+	config.ContentType = "application/json"
+	config.NegotiatedSerializer = serializer.DirectCodecFactory{CodecFactory: scheme.Codecs}
+	if config.UserAgent == "" {
+		config.UserAgent = rest.DefaultKubernetesUserAgent()
+	}
+	return rest.RESTClientFor(config)
+}
+
+// contextResourceClient is a dynamic.ResourceInterface whose requests are bound to its context:
+// they are canceled once the context is done. It behaves like the resource clients of
+// the dynamic client, but binds each request to the context instead of its transport,
+// so that the clients of all contexts share one REST client.
+type contextResourceClient struct {
+	ctx       context.Context
+	client    *rest.RESTClient
+	resource  schema.GroupVersionResource
+	namespace string
+}
+
+var _ dynamic.ResourceInterface = &contextResourceClient{}
+
+func (c *contextResourceClient) Create(obj *unstructured.Unstructured, subresources ...string) (*unstructured.Unstructured, error) {
+	body, err := runtime.Encode(unstructured.UnstructuredJSONScheme, obj)
+	if err != nil {
+		return nil, err
+	}
+	name := ""
+	if len(subresources) > 0 {
+		name = obj.GetName()
+	}
+	return c.object(c.do(c.client.Post().AbsPath(c.urlSegments(name, subresources...)...).Body(body)))
+}
+
+func (c *contextResourceClient) Update(obj *unstructured.Unstructured, subresources ...string) (*unstructured.Unstructured, error) {
+	body, err := runtime.Encode(unstructured.UnstructuredJSONScheme, obj)
+	if err != nil {
+		return nil, err
+	}
+	return c.object(c.do(c.client.Put().AbsPath(c.urlSegments(obj.GetName(), subresources...)...).Body(body)))
+}
+
+func (c *contextResourceClient) UpdateStatus(obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	return c.Update(obj, "status")
+}
+
+func (c *contextResourceClient) Delete(name string, opts *metav1.DeleteOptions, subresources ...string) error {
+	body, err := encodeDeleteOptions(opts)
+	if err != nil {
+		return err
+	}
+	return c.do(c.client.Delete().AbsPath(c.urlSegments(name, subresources...)...).Body(body)).Error()
+}
+
+func (c *contextResourceClient) DeleteCollection(opts *metav1.DeleteOptions, listOptions metav1.ListOptions) error {
+	body, err := encodeDeleteOptions(opts)
+	if err != nil {
+		return err
+	}
+	req := c.client.Delete().AbsPath(c.urlSegments("")...).Body(body).SpecificallyVersionedParams(&listOptions, scheme.ParameterCodec, versionV1)
+	return c.do(req).Error()
+}
+
+func (c *contextResourceClient) Get(name string, opts metav1.GetOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	req := c.client.Get().AbsPath(c.urlSegments(name, subresources...)...).SpecificallyVersionedParams(&opts, scheme.ParameterCodec, versionV1)
+	return c.object(c.do(req))
+}
+
+func (c *contextResourceClient) List(opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	req := c.client.Get().AbsPath(c.urlSegments("")...).SpecificallyVersionedParams(&opts, scheme.ParameterCodec, versionV1)
+	obj, err := decodeResult(c.do(req))
+	if err != nil {
+		return nil, err
+	}
+	if list, ok := obj.(*unstructured.UnstructuredList); ok {
+		return list, nil
+	}
+	return obj.(*unstructured.Unstructured).ToList()
+}
+
+func (c *contextResourceClient) Watch(opts metav1.ListOptions) (watch.Interface, error) {
+	opts.Watch = true
+	req := c.client.Get().AbsPath(c.urlSegments("")...).SpecificallyVersionedParams(&opts, scheme.ParameterCodec, versionV1)
+	return req.Context(c.ctx).WatchWithSpecificDecoders(func(body io.ReadCloser) streaming.Decoder {
+		return streaming.NewDecoder(json.Framer.NewFrameReader(body), watchEventSerializer)
+	}, unstructured.UnstructuredJSONScheme)
+}
+
+func (c *contextResourceClient) Patch(name string, pt types.PatchType, data []byte, subresources ...string) (*unstructured.Unstructured, error) {
+	return c.object(c.do(c.client.Patch(pt).AbsPath(c.urlSegments(name, subresources...)...).Body(data)))
+}
+
+// do sends the request with the context of the client.
+func (c *contextResourceClient) do(req *rest.Request) rest.Result {
+	return req.Context(c.ctx).Do()
+}
+
+// object returns the object the result holds.
+func (c *contextResourceClient) object(result rest.Result) (*unstructured.Unstructured, error) {
+	obj, err := decodeResult(result)
+	if err != nil {
+		return nil, err
+	}
+	return obj.(*unstructured.Unstructured), nil
+}
+
+// urlSegments returns the path of the resource, or of the object of the given name and its subresources.
+func (c *contextResourceClient) urlSegments(name string, subresources ...string) []string {
+	segments := []string{"apis", c.resource.Group, c.resource.Version}
+	if c.resource.Group == "" {
+		segments = []string{"api", c.resource.Version}
+	}
+	if c.namespace != "" {
+		segments = append(segments, "namespaces", c.namespace)
+	}
+	segments = append(segments, c.resource.Resource)
+	if name != "" {
+		segments = append(segments, name)
+	}
+	return append(segments, subresources...)
+}
+
+// decodeResult decodes the object or list the result of a request holds.
+func decodeResult(result rest.Result) (runtime.Object, error) {
+	if err := result.Error(); err != nil {
+		return nil, err
+	}
+	body, err := result.Raw()
+	if err != nil {
+		return nil, err
+	}
+	return runtime.Decode(unstructured.UnstructuredJSONScheme, body)
+}
+
+func encodeDeleteOptions(opts *metav1.DeleteOptions) ([]byte, error) {
+	if opts == nil {
+		opts = &metav1.DeleteOptions{}
+	}
+	return runtime.Encode(scheme.Codecs.LegacyCodec(versionV1), opts)
+}
@@ -0,0 +1,98 @@
+// Copyright 2018 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8sclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+)
+
+func TestContextResourceClient(t *testing.T) {
+	block := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/namespaces/ns1/pods/a":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"apiVersion":"v1","kind":"Pod","metadata":{"name":"a","namespace":"ns1"}}`))
+		case "/api/v1/namespaces/ns1/pods/slow":
+			<-block
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	// The slow request is unblocked before the server is closed, which waits for it.
+	defer close(block)
+	restClient, err := newContextRESTClient(&rest.Config{Host: server.URL})
+	if err != nil {
+		t.Fatalf("failed to create REST client: %v", err)
+	}
+
+	type Scenario struct {
+		name string
+		// timeout is the timeout of the context of the client, none if 0.
+		timeout     time.Duration
+		objectName  string
+		expectError bool
+	}
+
+	tests := []Scenario{
+		Scenario{
+			name:       "Get the object",
+			objectName: "a",
+		},
+		Scenario{
+			name:        "Missing object",
+			objectName:  "b",
+			expectError: true,
+		},
+		Scenario{
+			name:        "Request canceled once the context is done",
+			timeout:     50 * time.Millisecond,
+			objectName:  "slow",
+			expectError: true,
+		},
+	}
+
+	for _, test := range tests {
+		ctx, cancel := context.WithCancel(context.TODO())
+		if test.timeout > 0 {
+			ctx, cancel = context.WithTimeout(ctx, test.timeout)
+		}
+		client := &contextResourceClient{ctx: ctx, client: restClient, resource: schema.GroupVersionResource{Version: "v1", Resource: "pods"}, namespace: "ns1"}
+		obj, err := client.Get(test.objectName, metav1.GetOptions{})
+		cancel()
+		if test.expectError {
+			if err == nil {
+				t.Errorf("test %s failed, expected an error", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("test %s failed: %v", test.name, err)
+			continue
+		}
+		if obj.GetName() != test.objectName || obj.GetKind() != "Pod" {
+			t.Errorf("test %s failed, expected pod: %s; got: %s %s", test.name, test.objectName, obj.GetKind(), obj.GetName())
+		}
+	}
+}
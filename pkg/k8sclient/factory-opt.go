@@ -0,0 +1,125 @@
+// Copyright 2018 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8sclient
+
+import (
+	"time"
+
+	"k8s.io/client-go/rest"
+)
+
+// factoryOp wraps all the options for NewFactory().
+type factoryOp struct {
+	qps               float32
+	burst             int
+	userAgent         string
+	timeout           time.Duration
+	impersonate       rest.ImpersonationConfig
+	kubeConfigContext string
+	// minRefreshInterval is the minimum time between two refreshes of the discovery information.
+	minRefreshInterval time.Duration
+}
+
+// newFactoryOp creates a new default factoryOp
+func newFactoryOp() *factoryOp {
+	return &factoryOp{minRefreshInterval: defaultMinRefreshInterval}
+}
+
+func (op *factoryOp) applyOpts(opts []FactoryOption) {
+	for _, opt := range opts {
+		opt(op)
+	}
+}
+
+// applyToConfig sets the options on the config of the clients.
+func (op *factoryOp) applyToConfig(config *rest.Config) {
+	if op.qps != 0 {
+		config.QPS = op.qps
+	}
+	if op.burst != 0 {
+		config.Burst = op.burst
+	}
+	if op.userAgent != "" {
+		config.UserAgent = op.userAgent
+	}
+	if op.timeout != 0 {
+		config.Timeout = op.timeout
+	}
+	if op.impersonate.UserName != "" {
+		config.Impersonate = op.impersonate
+	}
+}
+
+// FactoryOption configures factoryOp.
+type FactoryOption func(*factoryOp)
+
+// WithQPS sets the maximum number of queries per second from the clients to the API server.
+// The default is 5.
+func WithQPS(qps float32) FactoryOption {
+	return func(op *factoryOp) {
+		op.qps = qps
+	}
+}
+
+// WithBurst sets the maximum burst of queries above QPS from the clients to the API server.
+// The default is 10.
+func WithBurst(burst int) FactoryOption {
+	return func(op *factoryOp) {
+		op.burst = burst
+	}
+}
+
+// WithUserAgent sets the user agent of the requests to the API server, e.g to tell the
+// requests of an operator in the audit log. The default is the client-go user agent.
+func WithUserAgent(userAgent string) FactoryOption {
+	return func(op *factoryOp) {
+		op.userAgent = userAgent
+	}
+}
+
+// WithTimeout sets the timeout of each request to the API server.
+// The default is no timeout. The timeout also ends the watches of the informers
+// after that time, which then start a new watch.
+func WithTimeout(timeout time.Duration) FactoryOption {
+	return func(op *factoryOp) {
+		op.timeout = timeout
+	}
+}
+
+// WithImpersonation makes the clients act as the given user and groups,
+// which the operator's service account needs the permission to impersonate.
+func WithImpersonation(userName string, groups ...string) FactoryOption {
+	return func(op *factoryOp) {
+		op.impersonate = rest.ImpersonationConfig{UserName: userName, Groups: groups}
+	}
+}
+
+// WithKubeConfigContext makes the clients use the given context of the kubeconfig file
+// in KUBERNETES_CONFIG, or of the default kubeconfig files, instead of the in-cluster config
+// or the current context.
+func WithKubeConfigContext(context string) FactoryOption {
+	return func(op *factoryOp) {
+		op.kubeConfigContext = context
+	}
+}
+
+// WithMinRefreshInterval sets the minimum time between two refreshes of the discovery
+// information, which is refreshed when a kind is not found, e.g because its CRD was just created.
+// A kind that is still not found is not found again until the interval passed. The default is 10s.
+func WithMinRefreshInterval(interval time.Duration) FactoryOption {
+	return func(op *factoryOp) {
+		op.minRefreshInterval = interval
+	}
+}
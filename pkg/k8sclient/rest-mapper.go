@@ -0,0 +1,123 @@
+// Copyright 2018 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8sclient
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached"
+	"k8s.io/client-go/restmapper"
+)
+
+// defaultMinRefreshInterval is the default minimum time between two refreshes
+// of the discovery information, see WithMinRefreshInterval.
+const defaultMinRefreshInterval = 10 * time.Second
+
+// DiscoveryMetrics records the refreshes of the discovery information of the factories.
+type DiscoveryMetrics interface {
+	// ObserveRefresh records a refresh that took duration. err is the error of the
+	// lookup of the kind that caused the refresh, nil if the kind was found.
+	ObserveRefresh(duration time.Duration, err error)
+	// ObserveSkippedRefresh records a refresh that was skipped because the
+	// previous one was too recent.
+	ObserveSkippedRefresh()
+}
+
+type noopDiscoveryMetrics struct{}
+
+func (noopDiscoveryMetrics) ObserveRefresh(time.Duration, error) {}
+func (noopDiscoveryMetrics) ObserveSkippedRefresh()              {}
+
+var (
+	discoveryMetrics   DiscoveryMetrics = noopDiscoveryMetrics{}
+	discoveryMetricsMu sync.RWMutex
+)
+
+// SetDiscoveryMetrics sets the metrics that the refreshes of the discovery information are recorded with.
+func SetDiscoveryMetrics(m DiscoveryMetrics) {
+	discoveryMetricsMu.Lock()
+	defer discoveryMetricsMu.Unlock()
+	discoveryMetrics = m
+}
+
+func getDiscoveryMetrics() DiscoveryMetrics {
+	discoveryMetricsMu.RLock()
+	defer discoveryMetricsMu.RUnlock()
+	return discoveryMetrics
+}
+
+// lazyRESTMapper maps kinds to resources with the cached discovery information of the API server.
+// The cache is refreshed when a kind has no match, e.g because its CRD was created after
+// the last refresh, at most once per minRefreshInterval.
+type lazyRESTMapper struct {
+	mapper             *restmapper.DeferredDiscoveryRESTMapper
+	minRefreshInterval time.Duration
+
+	mu sync.Mutex
+	// refreshes counts the refreshes, to tell if the cache was refreshed during a lookup.
+	refreshes   int
+	lastRefresh time.Time
+	stopped     bool
+}
+
+func newLazyRESTMapper(client discovery.DiscoveryInterface, minRefreshInterval time.Duration) *lazyRESTMapper {
+	return &lazyRESTMapper{
+		mapper:             restmapper.NewDeferredDiscoveryRESTMapper(cached.NewMemCacheClient(client)),
+		minRefreshInterval: minRefreshInterval,
+	}
+}
+
+// RESTMapping returns the REST mapping of the kind, refreshing the cache if the kind has no match.
+func (m *lazyRESTMapper) RESTMapping(gk schema.GroupKind, versions ...string) (*meta.RESTMapping, error) {
+	m.mu.Lock()
+	refreshes := m.refreshes
+	m.mu.Unlock()
+
+	mapping, err := m.mapper.RESTMapping(gk, versions...)
+	if !meta.IsNoMatchError(err) {
+		return mapping, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	switch {
+	case m.stopped:
+		return nil, err
+	case m.refreshes != refreshes:
+		// The cache was refreshed since the lookup, which can be retried without a refresh.
+		return m.mapper.RESTMapping(gk, versions...)
+	case time.Since(m.lastRefresh) < m.minRefreshInterval:
+		getDiscoveryMetrics().ObserveSkippedRefresh()
+		return nil, err
+	}
+	start := time.Now()
+	m.mapper.Reset()
+	m.refreshes++
+	m.lastRefresh = start
+	mapping, err = m.mapper.RESTMapping(gk, versions...)
+	getDiscoveryMetrics().ObserveRefresh(time.Since(start), err)
+	return mapping, err
+}
+
+// stop stops refreshing the cache. Kinds are then mapped with the information cached so far.
+func (m *lazyRESTMapper) stop() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stopped = true
+}
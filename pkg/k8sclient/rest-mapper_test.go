@@ -0,0 +1,117 @@
+// Copyright 2018 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8sclient
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+)
+
+// fakeDiscovery serves the discovery information of its resources, and counts the requests for groups.
+type fakeDiscovery struct {
+	*fakediscovery.FakeDiscovery
+	resources []*metav1.APIResourceList
+	requests  int
+}
+
+func (d *fakeDiscovery) ServerGroups() (*metav1.APIGroupList, error) {
+	d.requests++
+	groups := &metav1.APIGroupList{}
+	for _, l := range d.resources {
+		gv, err := schema.ParseGroupVersion(l.GroupVersion)
+		if err != nil {
+			return nil, err
+		}
+		version := metav1.GroupVersionForDiscovery{GroupVersion: l.GroupVersion, Version: gv.Version}
+		groups.Groups = append(groups.Groups, metav1.APIGroup{Name: gv.Group, Versions: []metav1.GroupVersionForDiscovery{version}, PreferredVersion: version})
+	}
+	return groups, nil
+}
+
+func (d *fakeDiscovery) ServerResourcesForGroupVersion(groupVersion string) (*metav1.APIResourceList, error) {
+	for _, l := range d.resources {
+		if l.GroupVersion == groupVersion {
+			return l, nil
+		}
+	}
+	return nil, errors.NewNotFound(schema.GroupResource{}, groupVersion)
+}
+
+type fakeDiscoveryMetrics struct {
+	refreshes, skipped int
+}
+
+func (m *fakeDiscoveryMetrics) ObserveRefresh(time.Duration, error) { m.refreshes++ }
+func (m *fakeDiscoveryMetrics) ObserveSkippedRefresh()              { m.skipped++ }
+
+func TestLazyRESTMapper(t *testing.T) {
+	metrics := &fakeDiscoveryMetrics{}
+	SetDiscoveryMetrics(metrics)
+	defer SetDiscoveryMetrics(noopDiscoveryMetrics{})
+
+	d := &fakeDiscovery{resources: []*metav1.APIResourceList{{
+		GroupVersion: "v1",
+		APIResources: []metav1.APIResource{{Name: "pods", Kind: "Pod", Namespaced: true}},
+	}}}
+	m := newLazyRESTMapper(d, time.Hour)
+	memcached := schema.GroupKind{Group: "cache.example.com", Kind: "Memcached"}
+
+	if _, err := m.RESTMapping(schema.GroupKind{Kind: "Pod"}, "v1"); err != nil {
+		t.Fatalf("failed to map kind Pod: %v", err)
+	}
+	if _, err := m.RESTMapping(memcached, "v1alpha1"); !meta.IsNoMatchError(err) {
+		t.Errorf("expected no match error; got: %v", err)
+	}
+	if metrics.refreshes != 1 {
+		t.Errorf("expected refreshes: 1; got: %d", metrics.refreshes)
+	}
+
+	// The CRD is created, but the last refresh is too recent.
+	d.resources = append(d.resources, &metav1.APIResourceList{
+		GroupVersion: "cache.example.com/v1alpha1",
+		APIResources: []metav1.APIResource{{Name: "memcacheds", Kind: "Memcached", Namespaced: true}},
+	})
+	if _, err := m.RESTMapping(memcached, "v1alpha1"); !meta.IsNoMatchError(err) {
+		t.Errorf("expected no match error; got: %v", err)
+	}
+	if metrics.skipped != 1 {
+		t.Errorf("expected skipped refreshes: 1; got: %d", metrics.skipped)
+	}
+
+	m.minRefreshInterval = 0
+	mapping, err := m.RESTMapping(memcached, "v1alpha1")
+	if err != nil {
+		t.Fatalf("failed to map kind Memcached: %v", err)
+	}
+	if mapping.Resource.Resource != "memcacheds" {
+		t.Errorf("expected resource: memcacheds; got: %s", mapping.Resource.Resource)
+	}
+
+	// A stopped mapper uses the information cached so far.
+	m.stop()
+	requests := d.requests
+	if _, err := m.RESTMapping(schema.GroupKind{Kind: "Secret"}, "v1"); !meta.IsNoMatchError(err) {
+		t.Errorf("expected no match error; got: %v", err)
+	}
+	if d.requests != requests {
+		t.Errorf("expected no discovery requests after stop; got: %d", d.requests-requests)
+	}
+}
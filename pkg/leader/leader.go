@@ -0,0 +1,188 @@
+// Copyright 2018 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package leader
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/operator-framework/operator-sdk/pkg/k8sclient"
+	"github.com/operator-framework/operator-sdk/pkg/util/k8sutil"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+var (
+	// initialBackoff is the time to wait before the first retry to become the leader.
+	initialBackoff = time.Second
+	// maxBackoff is the maximum time to wait between attempts to become the leader.
+	maxBackoff = 16 * time.Second
+)
+
+// LockName returns the default lock name for the operator,
+// derived from the OPERATOR_NAME env variable.
+func LockName() (string, error) {
+	operatorName, err := k8sutil.GetOperatorName()
+	if err != nil {
+		return "", err
+	}
+	return operatorName + "-lock", nil
+}
+
+// Become ensures that the current pod is the leader within its namespace.
+// If the operator is not running in a cluster, leader election is skipped and nil is returned.
+// Become keeps trying to create a ConfigMap named lockName that is owned by the current pod.
+// Only one ConfigMap with that name can exist, so the pod that creates it is the leader for life.
+// When the leader pod is deleted, the garbage collector deletes the ConfigMap.
+// A lock whose owner pod is already gone is deleted right away so another pod can take over.
+// Returns ctx.Err() if ctx is done before the current pod becomes the leader.
+func Become(ctx context.Context, lockName string) error {
+	ns, err := k8sutil.GetOperatorNamespace()
+	if err != nil {
+		if err == k8sutil.ErrNoNamespace {
+			logrus.Info("Skipping leader election; not running in a cluster")
+			return nil
+		}
+		return err
+	}
+	podName, err := k8sutil.GetPodName()
+	if err != nil {
+		return err
+	}
+	f, err := k8sclient.DefaultFactory()
+	if err != nil {
+		return err
+	}
+	return become(ctx, f.KubeClient(), ns, podName, lockName)
+}
+
+func become(ctx context.Context, client kubernetes.Interface, ns, podName, lockName string) error {
+	owner, err := myOwnerRef(client, ns, podName)
+	if err != nil {
+		return err
+	}
+
+	backoff := initialBackoff
+	for {
+		existing, err := client.CoreV1().ConfigMaps(ns).Get(lockName, metav1.GetOptions{})
+		switch {
+		case err == nil:
+			if isOwnedBy(existing, owner) {
+				logrus.Infof("Found existing lock (%s/%s) owned by this pod", ns, lockName)
+				return nil
+			}
+			stale, err := isStale(client, existing)
+			if err != nil {
+				return err
+			}
+			if stale {
+				logrus.Infof("Deleting lock (%s/%s) left by a pod that no longer exists", ns, lockName)
+				err = client.CoreV1().ConfigMaps(ns).Delete(lockName, &metav1.DeleteOptions{
+					Preconditions: &metav1.Preconditions{UID: &existing.UID},
+				})
+				if err != nil && !apierrors.IsNotFound(err) && !apierrors.IsConflict(err) {
+					return fmt.Errorf("failed to delete stale lock (%s/%s): %v", ns, lockName, err)
+				}
+				continue
+			}
+			logrus.Infof("Not the leader. Waiting for lock (%s/%s)", ns, lockName)
+		case apierrors.IsNotFound(err):
+			_, err = client.CoreV1().ConfigMaps(ns).Create(newLock(ns, lockName, owner))
+			if err == nil {
+				logrus.Infof("Became the leader with lock (%s/%s)", ns, lockName)
+				return nil
+			}
+			if !apierrors.IsAlreadyExists(err) {
+				return fmt.Errorf("failed to create lock (%s/%s): %v", ns, lockName, err)
+			}
+		default:
+			return fmt.Errorf("failed to get lock (%s/%s): %v", ns, lockName, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+// myOwnerRef returns an OwnerReference that points to the current pod.
+func myOwnerRef(client kubernetes.Interface, ns, podName string) (*metav1.OwnerReference, error) {
+	pod, err := client.CoreV1().Pods(ns).Get(podName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pod (%s/%s): %v", ns, podName, err)
+	}
+	return &metav1.OwnerReference{
+		APIVersion: "v1",
+		Kind:       "Pod",
+		Name:       pod.Name,
+		UID:        pod.UID,
+	}, nil
+}
+
+func newLock(ns, lockName string, owner *metav1.OwnerReference) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "ConfigMap",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            lockName,
+			Namespace:       ns,
+			OwnerReferences: []metav1.OwnerReference{*owner},
+		},
+	}
+}
+
+func isOwnedBy(lock *corev1.ConfigMap, owner *metav1.OwnerReference) bool {
+	for _, ref := range lock.GetOwnerReferences() {
+		if ref.Kind == owner.Kind && ref.Name == owner.Name && ref.UID == owner.UID {
+			return true
+		}
+	}
+	return false
+}
+
+// isStale returns true if the lock is owned by pods and none of them exist anymore.
+func isStale(client kubernetes.Interface, lock *corev1.ConfigMap) (bool, error) {
+	stale := false
+	for _, ref := range lock.GetOwnerReferences() {
+		if ref.Kind != "Pod" {
+			continue
+		}
+		stale = true
+		pod, err := client.CoreV1().Pods(lock.Namespace).Get(ref.Name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return false, fmt.Errorf("failed to get lock owner (%s/%s): %v", lock.Namespace, ref.Name, err)
+		}
+		// A pod with the same name may have been created after the owner was deleted.
+		if pod.UID == ref.UID && pod.Status.Phase != corev1.PodFailed {
+			return false, nil
+		}
+	}
+	return stale, nil
+}
@@ -0,0 +1,144 @@
+// Copyright 2018 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package leader
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const (
+	testNamespace = "operators"
+	testLockName  = "app-operator-lock"
+)
+
+func init() {
+	initialBackoff = 10 * time.Millisecond
+	maxBackoff = 20 * time.Millisecond
+}
+
+func newPod(name string, uid types.UID) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: testNamespace,
+			UID:       uid,
+		},
+	}
+}
+
+func lockOwner(t *testing.T, client kubernetes.Interface) string {
+	lock, err := client.CoreV1().ConfigMaps(testNamespace).Get(testLockName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to get lock: %v", err)
+	}
+	if len(lock.OwnerReferences) != 1 {
+		t.Fatalf("expected lock to have 1 owner; got: %v", lock.OwnerReferences)
+	}
+	return lock.OwnerReferences[0].Name
+}
+
+func TestBecomeCreatesLock(t *testing.T) {
+	client := fake.NewSimpleClientset(newPod("operator-a", "uid-a"))
+	if err := become(context.TODO(), client, testNamespace, "operator-a", testLockName); err != nil {
+		t.Fatalf("failed to become leader: %v", err)
+	}
+	if owner := lockOwner(t, client); owner != "operator-a" {
+		t.Errorf("expected lock owner: operator-a; got: %s", owner)
+	}
+
+	// Becoming the leader again is a no-op for the current leader.
+	if err := become(context.TODO(), client, testNamespace, "operator-a", testLockName); err != nil {
+		t.Errorf("failed to become leader again: %v", err)
+	}
+}
+
+func TestBecomeWaitsForLeader(t *testing.T) {
+	client := fake.NewSimpleClientset(newPod("operator-a", "uid-a"), newPod("operator-b", "uid-b"))
+	if err := become(context.TODO(), client, testNamespace, "operator-a", testLockName); err != nil {
+		t.Fatalf("failed to become leader: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 100*time.Millisecond)
+	defer cancel()
+	if err := become(ctx, client, testNamespace, "operator-b", testLockName); err != context.DeadlineExceeded {
+		t.Errorf("expected error: %v; got: %v", context.DeadlineExceeded, err)
+	}
+	if owner := lockOwner(t, client); owner != "operator-a" {
+		t.Errorf("expected lock owner: operator-a; got: %s", owner)
+	}
+}
+
+func TestBecomeTakesOverOnPodDeletion(t *testing.T) {
+	client := fake.NewSimpleClientset(newPod("operator-a", "uid-a"), newPod("operator-b", "uid-b"))
+	if err := become(context.TODO(), client, testNamespace, "operator-a", testLockName); err != nil {
+		t.Fatalf("failed to become leader: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 5*time.Second)
+	defer cancel()
+	errCh := make(chan error)
+	go func() {
+		errCh <- become(ctx, client, testNamespace, "operator-b", testLockName)
+	}()
+
+	// The fake clientset has no garbage collector, so the lock outlives its owner.
+	time.Sleep(50 * time.Millisecond)
+	if err := client.CoreV1().Pods(testNamespace).Delete("operator-a", &metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("failed to delete leader pod: %v", err)
+	}
+
+	if err := <-errCh; err != nil {
+		t.Fatalf("failed to take over leadership: %v", err)
+	}
+	if owner := lockOwner(t, client); owner != "operator-b" {
+		t.Errorf("expected lock owner: operator-b; got: %s", owner)
+	}
+}
+
+func TestBecomeTakesOverFromRecreatedPod(t *testing.T) {
+	client := fake.NewSimpleClientset(newPod("operator-a", "uid-a"))
+	if err := become(context.TODO(), client, testNamespace, "operator-a", testLockName); err != nil {
+		t.Fatalf("failed to become leader: %v", err)
+	}
+
+	// A pod with the same name but a different UID does not own the lock.
+	if err := client.CoreV1().Pods(testNamespace).Delete("operator-a", &metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("failed to delete leader pod: %v", err)
+	}
+	if _, err := client.CoreV1().Pods(testNamespace).Create(newPod("operator-a", "uid-a2")); err != nil {
+		t.Fatalf("failed to recreate pod: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 5*time.Second)
+	defer cancel()
+	if err := become(ctx, client, testNamespace, "operator-a", testLockName); err != nil {
+		t.Fatalf("failed to take over leadership: %v", err)
+	}
+	lock, err := client.CoreV1().ConfigMaps(testNamespace).Get(testLockName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to get lock: %v", err)
+	}
+	if uid := lock.OwnerReferences[0].UID; uid != "uid-a2" {
+		t.Errorf("expected lock owner UID: uid-a2; got: %s", uid)
+	}
+}
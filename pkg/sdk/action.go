@@ -15,11 +15,13 @@
 package sdk
 
 import (
+	"context"
 	"fmt"
 
-	"github.com/operator-framework/operator-sdk/pkg/k8sclient"
 	"github.com/operator-framework/operator-sdk/pkg/util/k8sutil"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/types"
 )
 
@@ -29,6 +31,17 @@ import (
 // Can also return an api error from the server
 // e.g AlreadyExists https://github.com/kubernetes/apimachinery/blob/master/pkg/api/errors/errors.go#L423
 func Create(object Object) (err error) {
+	return defaultClient.Create(object)
+}
+
+// CreateWithContext is like Create, but the request is canceled once ctx is done,
+// e.g when its deadline expires. It uses the Client from ClientFromContext(ctx).
+func CreateWithContext(ctx context.Context, object Object) error {
+	return ClientFromContext(ctx).Create(object)
+}
+
+// Create is like the package-level Create.
+func (c *dynamicClient) Create(object Object) (err error) {
 	_, namespace, err := k8sutil.GetNameAndNamespace(object)
 	if err != nil {
 		return err
@@ -36,7 +49,7 @@ func Create(object Object) (err error) {
 	gvk := object.GetObjectKind().GroupVersionKind()
 
 	apiVersion, kind := gvk.ToAPIVersionAndKind()
-	resourceClient, _, err := k8sclient.GetResourceClient(apiVersion, kind, namespace)
+	resourceClient, _, err := c.resourceClient(c.requestContext(), apiVersion, kind, namespace)
 	if err != nil {
 		return fmt.Errorf("failed to get resource client: %v", err)
 	}
@@ -65,6 +78,17 @@ func Create(object Object) (err error) {
 // Can also return an api error from the server
 // e.g Conflict https://github.com/kubernetes/apimachinery/blob/master/pkg/api/errors/errors.go#L428
 func Patch(object Object, pt types.PatchType, patch []byte) (err error) {
+	return defaultClient.Patch(object, pt, patch)
+}
+
+// PatchWithContext is like Patch, but the request is canceled once ctx is done.
+// It uses the Client from ClientFromContext(ctx).
+func PatchWithContext(ctx context.Context, object Object, pt types.PatchType, patch []byte) error {
+	return ClientFromContext(ctx).Patch(object, pt, patch)
+}
+
+// Patch is like the package-level Patch.
+func (c *dynamicClient) Patch(object Object, pt types.PatchType, patch []byte) (err error) {
 	name, namespace, err := k8sutil.GetNameAndNamespace(object)
 	if err != nil {
 		return err
@@ -72,7 +96,7 @@ func Patch(object Object, pt types.PatchType, patch []byte) (err error) {
 	gvk := object.GetObjectKind().GroupVersionKind()
 
 	apiVersion, kind := gvk.ToAPIVersionAndKind()
-	resourceClient, _, err := k8sclient.GetResourceClient(apiVersion, kind, namespace)
+	resourceClient, _, err := c.resourceClient(c.requestContext(), apiVersion, kind, namespace)
 	if err != nil {
 		return fmt.Errorf("failed to get resource client: %v", err)
 	}
@@ -96,6 +120,45 @@ func Patch(object Object, pt types.PatchType, patch []byte) (err error) {
 // Can also return an api error from the server
 // e.g Conflict https://github.com/kubernetes/apimachinery/blob/master/pkg/api/errors/errors.go#L428
 func Update(object Object) (err error) {
+	return defaultClient.Update(object)
+}
+
+// UpdateWithContext is like Update, but the request is canceled once ctx is done.
+// It uses the Client from ClientFromContext(ctx).
+func UpdateWithContext(ctx context.Context, object Object) error {
+	return ClientFromContext(ctx).Update(object)
+}
+
+// Update is like the package-level Update.
+func (c *dynamicClient) Update(object Object) (err error) {
+	return c.update(object, false)
+}
+
+// UpdateStatus updates the status of the provided object on the server and updates the arg
+// "object" with the result from the server(resourceVersion, etc).
+// The status subresource is used if it is enabled for the object's kind, e.g for a custom
+// resource whose CRD has "subresources: {status: {}}". Then only the status is updated and
+// changes to the rest of the object are ignored. Otherwise the whole object is updated like with Update.
+// Returns an error if the object’s TypeMeta(Kind, APIVersion) or ObjectMeta(Name, Namespace) is missing or incorrect.
+// Can also return an api error from the server
+// e.g Conflict https://github.com/kubernetes/apimachinery/blob/master/pkg/api/errors/errors.go#L428
+func UpdateStatus(object Object) (err error) {
+	return defaultClient.UpdateStatus(object)
+}
+
+// UpdateStatusWithContext is like UpdateStatus, but the requests are canceled once ctx is done.
+// It uses the Client from ClientFromContext(ctx).
+func UpdateStatusWithContext(ctx context.Context, object Object) error {
+	return ClientFromContext(ctx).UpdateStatus(object)
+}
+
+// UpdateStatus is like the package-level UpdateStatus.
+func (c *dynamicClient) UpdateStatus(object Object) (err error) {
+	return c.update(object, true)
+}
+
+// update updates the object, or its status subresource if status is true and the subresource exists.
+func (c *dynamicClient) update(object Object, status bool) (err error) {
 	_, namespace, err := k8sutil.GetNameAndNamespace(object)
 	if err != nil {
 		return err
@@ -103,7 +166,7 @@ func Update(object Object) (err error) {
 	gvk := object.GetObjectKind().GroupVersionKind()
 
 	apiVersion, kind := gvk.ToAPIVersionAndKind()
-	resourceClient, _, err := k8sclient.GetResourceClient(apiVersion, kind, namespace)
+	resourceClient, _, err := c.resourceClient(c.requestContext(), apiVersion, kind, namespace)
 	if err != nil {
 		return fmt.Errorf("failed to get resource client: %v", err)
 	}
@@ -112,10 +175,21 @@ func Update(object Object) (err error) {
 	if err != nil {
 		return err
 	}
-	unstructObj, err = resourceClient.Update(unstructObj)
+	var updated *unstructured.Unstructured
+	if status {
+		updated, err = resourceClient.UpdateStatus(unstructObj)
+		// The API server returns NotFound for the status of a kind without the status subresource.
+		if apierrors.IsNotFound(err) {
+			status = false
+		}
+	}
+	if !status {
+		updated, err = resourceClient.Update(unstructObj)
+	}
 	if err != nil {
 		return err
 	}
+	unstructObj = updated
 
 	// Update the arg object with the result
 	err = k8sutil.UnstructuredIntoRuntimeObject(unstructObj, object)
@@ -164,6 +238,17 @@ func WithDeleteOptions(metaDeleteOptions *metav1.DeleteOptions) DeleteOption {
 // “opts” configures the DeleteOptions
 // When passed WithDeleteOptions(o), the specified metav1.DeleteOptions are set.
 func Delete(object Object, opts ...DeleteOption) (err error) {
+	return defaultClient.Delete(object, opts...)
+}
+
+// DeleteWithContext is like Delete, but the request is canceled once ctx is done.
+// It uses the Client from ClientFromContext(ctx).
+func DeleteWithContext(ctx context.Context, object Object, opts ...DeleteOption) error {
+	return ClientFromContext(ctx).Delete(object, opts...)
+}
+
+// Delete is like the package-level Delete.
+func (c *dynamicClient) Delete(object Object, opts ...DeleteOption) (err error) {
 	name, namespace, err := k8sutil.GetNameAndNamespace(object)
 	if err != nil {
 		return err
@@ -171,7 +256,7 @@ func Delete(object Object, opts ...DeleteOption) (err error) {
 	gvk := object.GetObjectKind().GroupVersionKind()
 
 	apiVersion, kind := gvk.ToAPIVersionAndKind()
-	resourceClient, _, err := k8sclient.GetResourceClient(apiVersion, kind, namespace)
+	resourceClient, _, err := c.resourceClient(c.requestContext(), apiVersion, kind, namespace)
 	if err != nil {
 		return fmt.Errorf("failed to get resource client: %v", err)
 	}
@@ -16,18 +16,31 @@ package sdk
 
 import (
 	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/operator-framework/operator-sdk/pkg/k8sclient"
+	"github.com/operator-framework/operator-sdk/pkg/leader"
 	"github.com/operator-framework/operator-sdk/pkg/sdk/internal/metrics"
+	"github.com/operator-framework/operator-sdk/pkg/util/k8sutil"
 
 	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/util/workqueue"
 )
 
 var (
 	// informers is the set of all informers for the resources watched by the user
-	informers []Informer
-	collector *metrics.Collector
+	informers []*informer
+	// informersMu guards informers, which watches are added to and removed from while Run runs.
+	informersMu   sync.RWMutex
+	collectorOnce sync.Once
+	collector     *metrics.Collector
 )
 
 // Watch watches for changes on the given resource.
@@ -39,35 +52,166 @@ var (
 // resyncPeriod is the time period for how often an event with the latest resource version will be sent to the handler, even if there is no change.
 //   - 0 means no periodic events will be sent
 // Consult the API reference for the Group, Version and Kind of a resource: https://kubernetes.io/docs/reference/
-// namespace is the Namespace to watch for the resource
+// namespace is the Namespace to watch for the resource. It may be a comma separated list
+// of namespaces, e.g "ns1,ns2", to start an informer per namespace, or empty to watch
+// the resource in all namespaces. The value of WATCH_NAMESPACE can be passed as is.
 // TODO: support opts for specifying label selector
 func Watch(apiVersion, kind, namespace string, resyncPeriod time.Duration, opts ...watchOption) {
-	resourceClient, resourcePluralName, err := k8sclient.GetResourceClient(apiVersion, kind, namespace)
-	// TODO: Better error handling, e.g retry
-	if err != nil {
-		logrus.Errorf("failed to get resource client for (apiVersion:%s, kind:%s, ns:%s): %v", apiVersion, kind, namespace, err)
+	if _, err := AddWatch(apiVersion, kind, namespace, resyncPeriod, opts...); err != nil {
+		logrus.Error(err)
 		panic(err)
 	}
-	if collector == nil {
-		collector = metrics.New()
-		metrics.RegisterCollector(collector)
-	}
+}
+
+// AddWatch is like Watch, but returns an error instead of panicking, and the added watch,
+// which can be stopped with its Stop method. AddWatch may also be called while Run runs,
+// e.g to watch kinds that are only known at runtime; the watch is then started right away.
+// AddWatch is safe for concurrent use.
+func AddWatch(apiVersion, kind, namespace string, resyncPeriod time.Duration, opts ...watchOption) (*WatchHandle, error) {
 	o := newWatchOp()
 	o.applyOpts(opts)
-	informer := NewInformer(resourcePluralName, namespace, resourceClient, resyncPeriod, collector, o.numWorkers, o.labelSelector)
-	informers = append(informers, informer)
+	getResourceClient := clusterResourceClient(o.cluster)
+	// listWatchClient returns the resource client the informers list and watch the kind with.
+	listWatchClient := func(resourceClient dynamic.ResourceInterface, apiVersion, kind, ns string) dynamic.ResourceInterface {
+		if o.cluster == "" {
+			return resourceClient
+		}
+		return &clusterListWatchClient{
+			ResourceInterface: resourceClient,
+			resourceClient: func() (dynamic.ResourceInterface, error) {
+				resourceClient, _, err := getResourceClient(context.Background(), apiVersion, kind, ns)
+				return resourceClient, err
+			},
+		}
+	}
+	var namespaceSelector labels.Selector
+	var namespaces *namespaceInformer
+	if o.namespaceSelector != "" {
+		var err error
+		namespaceSelector, err = labels.Parse(o.namespaceSelector)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse namespace label selector (%s): %v", o.namespaceSelector, err)
+		}
+		namespaceClient, _, err := getResourceClient(context.Background(), "v1", "Namespace", metav1.NamespaceAll)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get resource client for namespaces: %v", err)
+		}
+		// The informers of the watch share the namespace informer.
+		namespaces = newNamespaceInformer(listWatchClient(namespaceClient, "v1", "Namespace", metav1.NamespaceAll), resyncPeriod)
+	}
+	c := getCollector()
+	newWatchInformer := func(ns string, resourceClient dynamic.ResourceInterface, resourcePluralName string) *informer {
+		informer := newInformer(apiVersion, kind, resourcePluralName, ns, listWatchClient(resourceClient, apiVersion, kind, ns), resyncPeriod, c, o)
+		if namespaceSelector != nil {
+			informer.filterNamespaces(namespaceSelector, namespaces)
+		}
+		return informer
+	}
+	w := &WatchHandle{}
+	for _, ns := range k8sutil.ParseNamespaces(namespace) {
+		resourceClient, resourcePluralName, err := getResourceClient(context.Background(), apiVersion, kind, ns)
+		if o.waitForKind > 0 && meta.IsNoMatchError(err) {
+			ns := ns
+			w.waiters = append(w.waiters, &kindWaiter{
+				apiVersion:        apiVersion,
+				kind:              kind,
+				namespace:         ns,
+				interval:          o.waitForKind,
+				getResourceClient: getResourceClient,
+				newInformer: func(resourceClient dynamic.ResourceInterface, resourcePluralName string) *informer {
+					return newWatchInformer(ns, resourceClient, resourcePluralName)
+				},
+				watch: w,
+			})
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get resource client for (apiVersion:%s, kind:%s, ns:%s): %v", apiVersion, kind, ns, err)
+		}
+		w.informers = append(w.informers, newWatchInformer(ns, resourceClient, resourcePluralName))
+	}
+	addWatch(w)
+	return w, nil
 }
 
-// Handle registers the handler for all events.
-// In the future, we would have a mux-pattern to dispatch events to matched handlers.
+// Handle registers the handler for all events that have no handler
+// registered for their kind with HandleFor or HandleResultFor.
 func Handle(handler Handler) {
-	RegisteredHandler = handler
+	DefaultMux.HandleFallback(handler)
+	RegisteredHandler = DefaultMux
+}
+
+// HandleFor registers the handler for events on the given resource.
+// apiVersion and kind identify the resource in the same format as for Watch.
+// middlewares wrap the handler in order, e.g to add logging for just this kind.
+// Events on resources without a registered handler go to the handler set by Handle.
+func HandleFor(apiVersion, kind string, handler Handler, middlewares ...Middleware) {
+	DefaultMux.HandleFor(apiVersion, kind, handler, middlewares...)
+	RegisteredHandler = DefaultMux
+}
+
+// HandleResult registers the ResultHandler for all events that have no handler
+// registered for their kind with HandleFor or HandleResultFor.
+func HandleResult(handler ResultHandler) {
+	DefaultMux.HandleResultFallback(handler)
+	RegisteredHandler = DefaultMux
+}
+
+// HandleResultFor is like HandleFor, but registers a ResultHandler.
+func HandleResultFor(apiVersion, kind string, handler ResultHandler, middlewares ...Middleware) {
+	DefaultMux.HandleResultFor(apiVersion, kind, handler, middlewares...)
+	RegisteredHandler = DefaultMux
 }
 
 // Run starts the process of Watching resources, handling Events, and processing Actions
-func Run(ctx context.Context) {
-	for _, informer := range informers {
-		go informer.Run(ctx)
+// "opts" configures the Run operation.
+//  When passed WithLeaderElection(), Run waits until the operator becomes the leader.
+//  When passed WithGracefulShutdown(timeout), Run waits for in-flight events to be handled after ctx is done.
+func Run(ctx context.Context, opts ...runOption) {
+	o := newRunOp()
+	o.applyOpts(opts)
+	callLock := newHandlerLock(o.concurrencyMode)
+	getCollector().ConcurrencyMode.WithLabelValues(string(o.concurrencyMode)).Set(1)
+	if o.leaderElection {
+		atomic.StoreInt32(&leaderState, leaderWaiting)
+		if err := becomeLeader(ctx); err != nil {
+			if err == ctx.Err() {
+				return
+			}
+			logrus.Errorf("failed to become the leader: %v", err)
+			panic(err)
+		}
+		atomic.StoreInt32(&leaderState, leaderElected)
 	}
-	<-ctx.Done()
+
+	if o.shutdownTimeout == 0 {
+		startInformers(&runState{ctx: ctx, handlerCtx: ctx, callLock: callLock, wg: &sync.WaitGroup{}})
+		<-ctx.Done()
+		stopStartingInformers()
+		return
+	}
+	runAndDrain(ctx, o.shutdownTimeout, callLock)
+}
+
+// becomeLeader blocks until the operator pod holds the leader lock.
+func becomeLeader(ctx context.Context) error {
+	lockName, err := leader.LockName()
+	if err != nil {
+		return err
+	}
+	return leader.Become(ctx, lockName)
+}
+
+// getCollector returns the metrics collector, and registers it on first use.
+// It also sets the providers for the metrics of the work queues and of the discovery
+// refreshes, so it must be called before the first queue is created.
+// getCollector is safe for concurrent use, e.g by AddWatch.
+func getCollector() *metrics.Collector {
+	collectorOnce.Do(func() {
+		collector = metrics.New()
+		metrics.RegisterCollector(collector)
+		workqueue.SetProvider(collector.WorkqueueMetricsProvider())
+		k8sclient.SetDiscoveryMetrics(collector.DiscoveryMetrics())
+	})
+	return collector
 }
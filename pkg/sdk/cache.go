@@ -0,0 +1,175 @@
+// Copyright 2018 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/cache"
+)
+
+// OwnerUIDIndexFunc indexes objects by the UIDs of their owners.
+func OwnerUIDIndexFunc(obj interface{}) ([]string, error) {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return nil, err
+	}
+	var uids []string
+	for _, ref := range accessor.GetOwnerReferences() {
+		uids = append(uids, string(ref.UID))
+	}
+	return uids, nil
+}
+
+// LabelIndexFunc returns an IndexFunc that indexes objects by the value of the given label.
+func LabelIndexFunc(label string) cache.IndexFunc {
+	return func(obj interface{}) ([]string, error) {
+		accessor, err := meta.Accessor(obj)
+		if err != nil {
+			return nil, err
+		}
+		value, ok := accessor.GetLabels()[label]
+		if !ok {
+			return nil, nil
+		}
+		return []string{value}, nil
+	}
+}
+
+// cacheFor returns the informer whose cache holds all objects of the given
+// apiVersion and kind in the namespace of the cluster, or nil if there is none.
+func cacheFor(cluster, apiVersion, kind, namespace string) *informer {
+	informersMu.RLock()
+	defer informersMu.RUnlock()
+	for _, i := range informers {
+		if i.cluster != cluster || i.apiVersion != apiVersion || i.kind != kind {
+			continue
+		}
+		if i.namespace != namespace && i.namespace != metav1.NamespaceAll {
+			continue
+		}
+		// The cache of a filtered watch is missing the filtered out objects.
+		if i.labelSelector != "" || i.fieldSelector != "" || !i.sharedIndexInformer.HasSynced() {
+			continue
+		}
+		return i
+	}
+	return nil
+}
+
+// cachedGet returns a copy of the cached object with the given namespace and name.
+// Returns a NotFound error if the object is not in the cache.
+func (i *informer) cachedGet(namespace, name string) (*unstructured.Unstructured, error) {
+	key := name
+	if namespace != "" {
+		key = namespace + "/" + name
+	}
+	obj, exists, err := i.sharedIndexInformer.GetIndexer().GetByKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		gv, err := schema.ParseGroupVersion(i.apiVersion)
+		if err != nil {
+			return nil, err
+		}
+		return nil, errors.NewNotFound(gv.WithResource(i.resourcePluralName).GroupResource(), name)
+	}
+	return obj.(*unstructured.Unstructured).DeepCopy(), nil
+}
+
+// cachedList returns copies of the cached objects in the namespace that match
+// the label selector of opts. Other list options are ignored.
+func (i *informer) cachedList(namespace string, opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	selector := labels.Everything()
+	if opts.LabelSelector != "" {
+		var err error
+		selector, err = labels.Parse(opts.LabelSelector)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse label selector (%s): %v", opts.LabelSelector, err)
+		}
+	}
+
+	indexer := i.sharedIndexInformer.GetIndexer()
+	objs := indexer.List()
+	if namespace != metav1.NamespaceAll {
+		var err error
+		objs, err = indexer.ByIndex(cache.NamespaceIndex, namespace)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var matched []interface{}
+	for _, obj := range objs {
+		if selector.Matches(labels.Set(obj.(*unstructured.Unstructured).GetLabels())) {
+			matched = append(matched, obj)
+		}
+	}
+	return newUnstructuredList(i.apiVersion, i.kind, matched), nil
+}
+
+// indexedList returns copies of the objects of the given apiVersion and kind in
+// the namespace of the cluster whose index named indexName contains indexedValue.
+func indexedList(cluster, apiVersion, kind, namespace, indexName, indexedValue string) (*unstructured.UnstructuredList, error) {
+	found := false
+	var matched []interface{}
+	informersMu.RLock()
+	defer informersMu.RUnlock()
+	for _, i := range informers {
+		if i.cluster != cluster || i.apiVersion != apiVersion || i.kind != kind {
+			continue
+		}
+		if namespace != metav1.NamespaceAll && i.namespace != namespace && i.namespace != metav1.NamespaceAll {
+			continue
+		}
+		indexer := i.sharedIndexInformer.GetIndexer()
+		if _, ok := indexer.GetIndexers()[indexName]; !ok {
+			continue
+		}
+		found = true
+		objs, err := indexer.ByIndex(indexName, indexedValue)
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range objs {
+			if namespace == metav1.NamespaceAll || obj.(*unstructured.Unstructured).GetNamespace() == namespace {
+				matched = append(matched, obj)
+			}
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("no watch with index (%s) for (apiVersion:%s, kind:%s, ns:%s)", indexName, apiVersion, kind, namespace)
+	}
+	return newUnstructuredList(apiVersion, kind, matched), nil
+}
+
+// newUnstructuredList returns a list of the given apiVersion and item kind
+// holding copies of the objects.
+func newUnstructuredList(apiVersion, kind string, objs []interface{}) *unstructured.UnstructuredList {
+	l := &unstructured.UnstructuredList{Object: map[string]interface{}{}}
+	l.SetAPIVersion(apiVersion)
+	l.SetKind(kind + "List")
+	for _, obj := range objs {
+		l.Items = append(l.Items, *obj.(*unstructured.Unstructured).DeepCopy())
+	}
+	return l
+}
@@ -0,0 +1,170 @@
+// Copyright 2018 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+)
+
+func newTestPod(namespace, name, app string, owner types.UID) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	u.SetAPIVersion("v1")
+	u.SetKind("Pod")
+	u.SetNamespace(namespace)
+	u.SetName(name)
+	u.SetLabels(map[string]string{"app": app})
+	u.SetOwnerReferences([]metav1.OwnerReference{{APIVersion: "v1", Kind: "ConfigMap", Name: "owner", UID: owner}})
+	return u
+}
+
+func newTestInformer(t *testing.T, namespace string, objs ...*unstructured.Unstructured) *informer {
+	i := &informer{apiVersion: "v1", kind: "Pod", resourcePluralName: "pods", namespace: namespace}
+	i.sharedIndexInformer = cache.NewSharedIndexInformer(&cache.ListWatch{}, &unstructured.Unstructured{}, 0, cache.Indexers{
+		cache.NamespaceIndex: cache.MetaNamespaceIndexFunc,
+		"owner":              OwnerUIDIndexFunc,
+		"app":                LabelIndexFunc("app"),
+	})
+	for _, obj := range objs {
+		if err := i.sharedIndexInformer.GetIndexer().Add(obj); err != nil {
+			t.Fatalf("failed to add object to cache: %v", err)
+		}
+	}
+	return i
+}
+
+func listNames(l *unstructured.UnstructuredList) map[string]bool {
+	names := map[string]bool{}
+	for _, item := range l.Items {
+		names[item.GetNamespace()+"/"+item.GetName()] = true
+	}
+	return names
+}
+
+func TestCachedGet(t *testing.T) {
+	i := newTestInformer(t, "", newTestPod("ns1", "a", "web", "uid-1"))
+
+	u, err := i.cachedGet("ns1", "a")
+	if err != nil {
+		t.Fatalf("failed to get cached object: %v", err)
+	}
+	if u.GetName() != "a" {
+		t.Errorf("expected object: a; got: %s", u.GetName())
+	}
+	if _, err := i.cachedGet("ns2", "a"); !errors.IsNotFound(err) {
+		t.Errorf("expected not found error; got: %v", err)
+	}
+}
+
+func TestCachedList(t *testing.T) {
+	i := newTestInformer(t, "",
+		newTestPod("ns1", "a", "web", "uid-1"),
+		newTestPod("ns1", "b", "db", "uid-1"),
+		newTestPod("ns2", "c", "web", "uid-2"),
+	)
+
+	type Scenario struct {
+		name          string
+		namespace     string
+		labelSelector string
+		expected      []string
+	}
+
+	tests := []Scenario{
+		Scenario{
+			name:      "All namespaces",
+			namespace: metav1.NamespaceAll,
+			expected:  []string{"ns1/a", "ns1/b", "ns2/c"},
+		},
+		Scenario{
+			name:      "One namespace",
+			namespace: "ns1",
+			expected:  []string{"ns1/a", "ns1/b"},
+		},
+		Scenario{
+			name:          "Label selector",
+			namespace:     metav1.NamespaceAll,
+			labelSelector: "app=web",
+			expected:      []string{"ns1/a", "ns2/c"},
+		},
+	}
+
+	for _, test := range tests {
+		l, err := i.cachedList(test.namespace, metav1.ListOptions{LabelSelector: test.labelSelector})
+		if err != nil {
+			t.Errorf("test %s failed: %v", test.name, err)
+			continue
+		}
+		if l.GetKind() != "PodList" {
+			t.Errorf("test %s failed, expected list kind: PodList; got: %s", test.name, l.GetKind())
+		}
+		names := listNames(l)
+		if len(names) != len(test.expected) {
+			t.Errorf("test %s failed, expected objects: %v; got: %v", test.name, test.expected, names)
+			continue
+		}
+		for _, name := range test.expected {
+			if !names[name] {
+				t.Errorf("test %s failed, expected objects: %v; got: %v", test.name, test.expected, names)
+				break
+			}
+		}
+	}
+}
+
+func TestIndexedList(t *testing.T) {
+	dev := newTestInformer(t, "", newTestPod("ns3", "d", "web", "uid-2"))
+	dev.cluster = "dev"
+	informers = []*informer{newTestInformer(t, "",
+		newTestPod("ns1", "a", "web", "uid-1"),
+		newTestPod("ns1", "b", "db", "uid-1"),
+		newTestPod("ns2", "c", "web", "uid-1"),
+	), dev}
+	defer func() { informers = nil }()
+
+	l, err := indexedList("", "v1", "Pod", "ns1", "owner", "uid-1")
+	if err != nil {
+		t.Fatalf("failed to list by index: %v", err)
+	}
+	if names := listNames(l); len(names) != 2 || !names["ns1/a"] || !names["ns1/b"] {
+		t.Errorf("expected objects: [ns1/a ns1/b]; got: %v", names)
+	}
+
+	l, err = indexedList("", "v1", "Pod", metav1.NamespaceAll, "app", "web")
+	if err != nil {
+		t.Fatalf("failed to list by index: %v", err)
+	}
+	if names := listNames(l); len(names) != 2 || !names["ns1/a"] || !names["ns2/c"] {
+		t.Errorf("expected objects: [ns1/a ns2/c]; got: %v", names)
+	}
+
+	// The watches of another cluster have their own cache.
+	l, err = indexedList("dev", "v1", "Pod", metav1.NamespaceAll, "app", "web")
+	if err != nil {
+		t.Fatalf("failed to list by index: %v", err)
+	}
+	if names := listNames(l); len(names) != 1 || !names["ns3/d"] {
+		t.Errorf("expected objects: [ns3/d]; got: %v", names)
+	}
+
+	if _, err := indexedList("", "v1", "Pod", metav1.NamespaceAll, "missing", "web"); err == nil {
+		t.Error("expected an error for a missing index")
+	}
+}
@@ -0,0 +1,171 @@
+// Copyright 2018 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk
+
+import (
+	"context"
+
+	"github.com/operator-framework/operator-sdk/pkg/k8sclient"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+)
+
+// Client creates, updates, deletes and reads Kubernetes objects.
+// The methods behave like the package-level functions of the same name.
+// Handlers that get their Client with ClientFromContext can be unit tested
+// with a fake Client, see NewFakeClient.
+type Client interface {
+	Create(object Object) error
+	Update(object Object) error
+	UpdateStatus(object Object) error
+	Patch(object Object, pt types.PatchType, patch []byte) error
+	Delete(object Object, opts ...DeleteOption) error
+	Get(into Object, opts ...GetOption) error
+	List(namespace string, into Object, opts ...ListOption) error
+}
+
+// resourceClientFunc returns the dynamic resource client and the plural name for the
+// given apiVersion, kind and namespace, like k8sclient.GetResourceClientWithContext.
+// The requests of the resource client are canceled once ctx is done.
+type resourceClientFunc func(ctx context.Context, apiVersion, kind, namespace string) (dynamic.ResourceInterface, string, error)
+
+// dynamicClient is a Client backed by dynamic resource clients.
+type dynamicClient struct {
+	resourceClient resourceClientFunc
+	// useCache makes Get and List read watched kinds from the cache of the watch.
+	useCache bool
+	// ctx is the context the requests are bound to, if set. See withContext.
+	ctx context.Context
+	// cluster is the name of the cluster the client is for, empty for the default cluster.
+	cluster string
+}
+
+// defaultClient is the Client used by the package-level functions.
+var defaultClient Client = NewClient()
+
+// NewClient returns a Client backed by the dynamic client of pkg/k8sclient.
+// Like the package-level Get and List, it reads watched kinds from the cache.
+func NewClient() Client {
+	return &dynamicClient{resourceClient: k8sclient.GetResourceClientWithContext, useCache: true}
+}
+
+// NewClusterClient returns a Client for the cluster of the given name, backed by the client
+// factory set for the name with k8sclient.SetClusterFactory or k8sclient.SetClusterFactoryFromSecret.
+// It reads the kinds watched in that cluster with WithCluster from the cache.
+// Its methods return an error while no factory is set for the name.
+func NewClusterClient(cluster string) Client {
+	return &dynamicClient{resourceClient: clusterResourceClient(cluster), useCache: true, cluster: cluster}
+}
+
+// clusterResourceClient returns a resourceClientFunc for the cluster of the given name,
+// see k8sclient.ClusterFactory.
+func clusterResourceClient(cluster string) resourceClientFunc {
+	return func(ctx context.Context, apiVersion, kind, namespace string) (dynamic.ResourceInterface, string, error) {
+		f, err := k8sclient.ClusterFactory(cluster)
+		if err != nil {
+			return nil, "", err
+		}
+		return f.GetResourceClientWithContext(ctx, apiVersion, kind, namespace)
+	}
+}
+
+// clusterListWatchClient is the resource client of a watch of a cluster. It gets the resource
+// client from the factory of the cluster on each list and watch, so that a watched cluster is
+// not evicted as idle, see k8sclient.EvictIdleClusterFactories, and the watch uses the clients
+// of a factory that replaced it, e.g once its kubeconfig secret changed.
+type clusterListWatchClient struct {
+	dynamic.ResourceInterface
+	resourceClient func() (dynamic.ResourceInterface, error)
+}
+
+func (c *clusterListWatchClient) List(opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	resourceClient, err := c.resourceClient()
+	if err != nil {
+		return nil, err
+	}
+	return resourceClient.List(opts)
+}
+
+func (c *clusterListWatchClient) Watch(opts metav1.ListOptions) (watch.Interface, error) {
+	resourceClient, err := c.resourceClient()
+	if err != nil {
+		return nil, err
+	}
+	return resourceClient.Watch(opts)
+}
+
+// withContext returns a copy of the client whose requests are canceled once ctx is done.
+func (c *dynamicClient) withContext(ctx context.Context) Client {
+	cc := *c
+	cc.ctx = ctx
+	return &cc
+}
+
+// requestContext returns the context the requests of the client are bound to.
+func (c *dynamicClient) requestContext() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
+}
+
+// cacheFor returns the informer whose cache the client reads objects
+// of the given apiVersion and kind in the namespace from, or nil.
+func (c *dynamicClient) cacheFor(apiVersion, kind, namespace string) *informer {
+	if !c.useCache {
+		return nil
+	}
+	return cacheFor(c.cluster, apiVersion, kind, namespace)
+}
+
+type clientKey struct{}
+
+type clusterKey struct{}
+
+// ContextWithClient returns a copy of ctx that carries the client.
+// Handler unit tests can pass it to Handle to make the handler use a fake Client.
+func ContextWithClient(ctx context.Context, client Client) context.Context {
+	return context.WithValue(ctx, clientKey{}, client)
+}
+
+// ContextWithCluster returns a copy of ctx that targets the cluster of the given name, e.g
+// event.Cluster, so that the sdk actions that take ctx, like CreateWithContext, act on that cluster.
+// See NewClusterClient. A fake Client carried by ctx ignores the cluster.
+func ContextWithCluster(ctx context.Context, cluster string) context.Context {
+	return context.WithValue(ctx, clusterKey{}, cluster)
+}
+
+// ClientFromContext returns the Client carried by ctx, or the Client
+// used by the package-level functions if ctx carries none.
+// The requests of a Client created by NewClient are canceled once ctx is done,
+// e.g when the deadline of the handler's context expires, see WithReconcileTimeout,
+// and are sent to the cluster set by ContextWithCluster, if any.
+func ClientFromContext(ctx context.Context) Client {
+	client, ok := ctx.Value(clientKey{}).(Client)
+	if !ok {
+		client = defaultClient
+	}
+	if c, ok := client.(*dynamicClient); ok {
+		if cluster, ok := ctx.Value(clusterKey{}).(string); ok && cluster != c.cluster {
+			c = NewClusterClient(cluster).(*dynamicClient)
+		}
+		return c.withContext(ctx)
+	}
+	return client
+}
@@ -0,0 +1,54 @@
+// Copyright 2018 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"k8s.io/client-go/dynamic"
+)
+
+func TestClientFromContextBindsRequests(t *testing.T) {
+	var got context.Context
+	errStop := errors.New("stop")
+	client := &dynamicClient{resourceClient: func(ctx context.Context, apiVersion, kind, namespace string) (dynamic.ResourceInterface, string, error) {
+		got = ctx
+		return nil, "", errStop
+	}}
+
+	ctx, cancel := context.WithCancel(ContextWithClient(context.TODO(), client))
+	defer cancel()
+	ClientFromContext(ctx).Delete(newTypedPod("a", "web"))
+	if got != ctx {
+		t.Errorf("expected requests bound to the context of ClientFromContext")
+	}
+
+	// The client itself stays unbound.
+	client.Delete(newTypedPod("a", "web"))
+	if got != context.Background() {
+		t.Errorf("expected requests of an unbound client bound to the background context")
+	}
+}
+
+func TestClientFromContextWithCluster(t *testing.T) {
+	ctx := ContextWithCluster(context.TODO(), "missing")
+	err := ClientFromContext(ctx).Delete(newTypedPod("a", "web"))
+	if err == nil || !strings.Contains(err.Error(), "cluster (missing)") {
+		t.Errorf("expected an error for the missing cluster; got: %v", err)
+	}
+}
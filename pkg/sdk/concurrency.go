@@ -0,0 +1,99 @@
+// Copyright 2018 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk
+
+import (
+	"sync"
+)
+
+// ConcurrencyMode sets which calls into the handler may run concurrently.
+type ConcurrencyMode string
+
+const (
+	// ConcurrencyParallel runs the handler concurrently for any events, except for
+	// events on the same object. This is the default.
+	ConcurrencyParallel ConcurrencyMode = "parallel"
+	// ConcurrencySerial runs the handler for one event at a time, across all watches.
+	ConcurrencySerial ConcurrencyMode = "serial"
+	// ConcurrencyPerKey runs the handler for one event at a time per namespace/name key,
+	// across all watches. For example, the events on a CR and on a Deployment with the
+	// same namespace and name are handled one after the other.
+	ConcurrencyPerKey ConcurrencyMode = "per-key"
+)
+
+// handlerLock serializes the calls into the handler for the keys of the event objects.
+type handlerLock interface {
+	lock(key string)
+	unlock(key string)
+}
+
+// newHandlerLock returns the handlerLock for the concurrency mode set by Run.
+func newHandlerLock(mode ConcurrencyMode) handlerLock {
+	switch mode {
+	case ConcurrencySerial:
+		return &globalLock{}
+	case ConcurrencyPerKey:
+		return &keyLock{keys: map[string]*keyLockEntry{}}
+	default:
+		return noLock{}
+	}
+}
+
+type noLock struct{}
+
+func (noLock) lock(string)   {}
+func (noLock) unlock(string) {}
+
+type globalLock struct {
+	mu sync.Mutex
+}
+
+func (l *globalLock) lock(string)   { l.mu.Lock() }
+func (l *globalLock) unlock(string) { l.mu.Unlock() }
+
+// keyLock holds a mutex per key that is locked or waited for.
+type keyLock struct {
+	mu   sync.Mutex
+	keys map[string]*keyLockEntry
+}
+
+type keyLockEntry struct {
+	mu sync.Mutex
+	// refs is the number of callers that hold or wait for mu.
+	refs int
+}
+
+func (l *keyLock) lock(key string) {
+	l.mu.Lock()
+	e, ok := l.keys[key]
+	if !ok {
+		e = &keyLockEntry{}
+		l.keys[key] = e
+	}
+	e.refs++
+	l.mu.Unlock()
+
+	e.mu.Lock()
+}
+
+func (l *keyLock) unlock(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	e := l.keys[key]
+	e.mu.Unlock()
+	if e.refs--; e.refs == 0 {
+		delete(l.keys, key)
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright 2018 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk
+
+import (
+	"testing"
+	"time"
+)
+
+func TestKeyLock(t *testing.T) {
+	l := newHandlerLock(ConcurrencyPerKey).(*keyLock)
+	l.lock("ns1/a")
+
+	// Other keys are not blocked.
+	l.lock("ns1/b")
+	l.unlock("ns1/b")
+
+	locked := make(chan struct{})
+	go func() {
+		l.lock("ns1/a")
+		close(locked)
+		l.unlock("ns1/a")
+	}()
+	select {
+	case <-locked:
+		t.Fatalf("expected key ns1/a to stay locked")
+	case <-time.After(50 * time.Millisecond):
+	}
+	l.unlock("ns1/a")
+	select {
+	case <-locked:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected key ns1/a to be unlocked")
+	}
+
+	// Unused keys are removed.
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if n := len(l.keys); n != 0 {
+		t.Errorf("expected no keys; got: %d", n)
+	}
+}
@@ -0,0 +1,298 @@
+// Copyright 2018 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/operator-framework/operator-sdk/pkg/util/k8sutil"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// fakeClient is an in-memory Client backed by an object tracker.
+type fakeClient struct {
+	// mu serializes the writes, so that resourceVersion checks are consistent,
+	// and the registration of list kinds in scheme.
+	mu              sync.Mutex
+	scheme          *runtime.Scheme
+	tracker         k8stesting.ObjectTracker
+	resourceVersion int
+}
+
+// NewFakeClient returns an in-memory Client that holds the given objects, for handler unit tests:
+//
+//	client := sdk.NewFakeClient(memcached)
+//	err := handler.Handle(sdk.ContextWithClient(context.TODO(), client), sdk.Event{Object: memcached})
+//
+// Objects are decoded like the objects read from the API server, so the types of custom
+// resources must be added with k8sutil.AddToSDKScheme. Like the API server, the fake client
+// sets the UID, creationTimestamp and resourceVersion of created objects, and fails updates
+// of objects with a stale resourceVersion with a Conflict error. UpdateStatus behaves as if
+// all kinds had the status subresource, and only updates the status. It does not read from the
+// cache of the watches, run admission or garbage collection, or apply DeleteOptions.
+// List only supports the metadata.name and metadata.namespace field selectors.
+// Strategic merge patches are only supported for the built-in types.
+func NewFakeClient(objects ...Object) Client {
+	c := &fakeClient{scheme: runtime.NewScheme()}
+	c.tracker = k8stesting.NewObjectTracker(c.scheme, unstructured.UnstructuredJSONScheme)
+	for _, object := range objects {
+		if err := c.Create(object.DeepCopyObject()); err != nil {
+			panic(fmt.Sprintf("failed to add object to fake client: %v", err))
+		}
+	}
+	return c
+}
+
+// Create is like the package-level Create.
+func (c *fakeClient) Create(object Object) error {
+	u, gvr, err := toUnstructured(object)
+	if err != nil {
+		return err
+	}
+	if u.GetName() == "" && u.GetGenerateName() != "" {
+		u.SetName(u.GetGenerateName() + utilrand.String(5))
+	}
+	u.SetUID(uuid.NewUUID())
+	u.SetCreationTimestamp(metav1.Now())
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.setResourceVersion(u)
+	if err := c.tracker.Create(gvr, u, u.GetNamespace()); err != nil {
+		return err
+	}
+	return k8sutil.UnstructuredIntoRuntimeObject(u, object)
+}
+
+// Update is like the package-level Update.
+func (c *fakeClient) Update(object Object) error {
+	return c.update(object, false)
+}
+
+// UpdateStatus is like the package-level UpdateStatus, for a kind with the status subresource.
+func (c *fakeClient) UpdateStatus(object Object) error {
+	return c.update(object, true)
+}
+
+// update updates the object, or only its status if status is true.
+func (c *fakeClient) update(object Object, status bool) error {
+	u, gvr, err := toUnstructured(object)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	existing, err := c.get(gvr, u.GetNamespace(), u.GetName())
+	if err != nil {
+		return err
+	}
+	if rv := u.GetResourceVersion(); rv != "" && rv != existing.GetResourceVersion() {
+		return apierrors.NewConflict(gvr.GroupResource(), u.GetName(),
+			fmt.Errorf("the object has been modified; please apply your changes to the latest version and try again"))
+	}
+	if status {
+		newStatus, found, err := unstructured.NestedFieldCopy(u.Object, "status")
+		if err != nil {
+			return apierrors.NewBadRequest(err.Error())
+		}
+		u = existing.DeepCopy()
+		if found {
+			u.Object["status"] = newStatus
+		} else {
+			delete(u.Object, "status")
+		}
+	}
+	u.SetUID(existing.GetUID())
+	u.SetCreationTimestamp(existing.GetCreationTimestamp())
+	c.setResourceVersion(u)
+	if err := c.tracker.Update(gvr, u, u.GetNamespace()); err != nil {
+		return err
+	}
+	return k8sutil.UnstructuredIntoRuntimeObject(u, object)
+}
+
+// Patch is like the package-level Patch.
+func (c *fakeClient) Patch(object Object, pt types.PatchType, patch []byte) error {
+	name, namespace, err := k8sutil.GetNameAndNamespace(object)
+	if err != nil {
+		return err
+	}
+	gvr := guessResource(object.GetObjectKind().GroupVersionKind())
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	existing, err := c.get(gvr, namespace, name)
+	if err != nil {
+		return err
+	}
+	original, err := json.Marshal(existing.Object)
+	if err != nil {
+		return err
+	}
+	var patched []byte
+	switch pt {
+	case types.JSONPatchType:
+		var p jsonpatch.Patch
+		if p, err = jsonpatch.DecodePatch(patch); err == nil {
+			patched, err = p.Apply(original)
+		}
+	case types.MergePatchType:
+		patched, err = jsonpatch.MergePatch(original, patch)
+	case types.StrategicMergePatchType:
+		var typed runtime.Object
+		if typed, err = k8sutil.RuntimeObjectFromUnstructured(existing); err == nil {
+			patched, err = strategicpatch.StrategicMergePatch(original, patch, typed)
+		}
+	default:
+		err = fmt.Errorf("unsupported patch type (%s)", pt)
+	}
+	if err != nil {
+		return apierrors.NewBadRequest(fmt.Sprintf("failed to apply patch: %v", err))
+	}
+
+	u := &unstructured.Unstructured{}
+	if err := json.Unmarshal(patched, &u.Object); err != nil {
+		return fmt.Errorf("failed to unmarshal patched object: %v", err)
+	}
+	c.setResourceVersion(u)
+	if err := c.tracker.Update(gvr, u, namespace); err != nil {
+		return err
+	}
+	return k8sutil.UnstructuredIntoRuntimeObject(u, object)
+}
+
+// Delete is like the package-level Delete.
+func (c *fakeClient) Delete(object Object, opts ...DeleteOption) error {
+	name, namespace, err := k8sutil.GetNameAndNamespace(object)
+	if err != nil {
+		return err
+	}
+	gvr := guessResource(object.GetObjectKind().GroupVersionKind())
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.tracker.Delete(gvr, namespace, name)
+}
+
+// Get is like the package-level Get.
+func (c *fakeClient) Get(into Object, opts ...GetOption) error {
+	name, namespace, err := k8sutil.GetNameAndNamespace(into)
+	if err != nil {
+		return err
+	}
+	u, err := c.get(guessResource(into.GetObjectKind().GroupVersionKind()), namespace, name)
+	if err != nil {
+		return err
+	}
+	return k8sutil.UnstructuredIntoRuntimeObject(u, into)
+}
+
+// List is like the package-level List.
+func (c *fakeClient) List(namespace string, into Object, opts ...ListOption) error {
+	gvk := into.GetObjectKind().GroupVersionKind()
+	o := NewListOp()
+	o.applyOpts(opts)
+	labelSelector, err := labels.Parse(o.metaListOptions.LabelSelector)
+	if err != nil {
+		return apierrors.NewBadRequest(err.Error())
+	}
+	fieldSelector, err := fields.ParseSelector(o.metaListOptions.FieldSelector)
+	if err != nil {
+		return apierrors.NewBadRequest(err.Error())
+	}
+	for _, r := range fieldSelector.Requirements() {
+		if r.Field != "metadata.name" && r.Field != "metadata.namespace" {
+			return apierrors.NewBadRequest(fmt.Sprintf("field selector (%s) is not supported by the fake client", r.Field))
+		}
+	}
+
+	c.mu.Lock()
+	listGVK := gvk.GroupVersion().WithKind(gvk.Kind + "List")
+	if !c.scheme.Recognizes(listGVK) {
+		c.scheme.AddKnownTypeWithName(listGVK, &unstructured.UnstructuredList{})
+	}
+	c.mu.Unlock()
+	obj, err := c.tracker.List(guessResource(gvk), gvk, namespace)
+	if err != nil {
+		return err
+	}
+
+	apiVersion, kind := gvk.ToAPIVersionAndKind()
+	var matched []interface{}
+	for _, item := range obj.(*unstructured.UnstructuredList).Items {
+		item := item
+		objectFields := fields.Set{"metadata.name": item.GetName(), "metadata.namespace": item.GetNamespace()}
+		if labelSelector.Matches(labels.Set(item.GetLabels())) && fieldSelector.Matches(objectFields) {
+			matched = append(matched, &item)
+		}
+	}
+	l := newUnstructuredList(apiVersion, kind, matched)
+	if err := k8sutil.RuntimeObjectIntoRuntimeObject(l, into); err != nil {
+		return fmt.Errorf("failed to unmarshal the retrieved data: %v", err)
+	}
+	return nil
+}
+
+// get returns the object with the given namespace and name from the tracker.
+func (c *fakeClient) get(gvr schema.GroupVersionResource, namespace, name string) (*unstructured.Unstructured, error) {
+	obj, err := c.tracker.Get(gvr, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	return obj.(*unstructured.Unstructured), nil
+}
+
+// setResourceVersion sets a new resourceVersion on the object. c.mu must be held.
+func (c *fakeClient) setResourceVersion(u *unstructured.Unstructured) {
+	c.resourceVersion++
+	u.SetResourceVersion(strconv.Itoa(c.resourceVersion))
+}
+
+// toUnstructured converts the object to an unstructured object,
+// and returns the resource of its kind.
+func toUnstructured(object Object) (*unstructured.Unstructured, schema.GroupVersionResource, error) {
+	if _, _, err := k8sutil.GetNameAndNamespace(object); err != nil {
+		return nil, schema.GroupVersionResource{}, err
+	}
+	u, err := k8sutil.UnstructuredFromRuntimeObject(object)
+	if err != nil {
+		return nil, schema.GroupVersionResource{}, err
+	}
+	return u, guessResource(u.GroupVersionKind()), nil
+}
+
+// guessResource returns the resource for the kind, e.g "pods" for "Pod".
+// The fake client has no discovery to look up the actual resource.
+func guessResource(gvk schema.GroupVersionKind) schema.GroupVersionResource {
+	gvr, _ := meta.UnsafeGuessKindToResource(gvk)
+	return gvr
+}
@@ -0,0 +1,106 @@
+// Copyright 2018 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func newTypedPod(name, app string) *corev1.Pod {
+	return &corev1.Pod{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "ns1",
+			Labels:    map[string]string{"app": app},
+		},
+	}
+}
+
+func TestFakeClient(t *testing.T) {
+	client := ClientFromContext(ContextWithClient(context.TODO(), NewFakeClient(newTypedPod("a", "web"))))
+
+	if err := client.Create(newTypedPod("b", "db")); err != nil {
+		t.Fatalf("failed to create pod: %v", err)
+	}
+	if err := client.Create(newTypedPod("b", "db")); !apierrors.IsAlreadyExists(err) {
+		t.Errorf("expected already exists error; got: %v", err)
+	}
+
+	pod := newTypedPod("a", "")
+	if err := client.Get(pod); err != nil {
+		t.Fatalf("failed to get pod: %v", err)
+	}
+	if pod.Labels["app"] != "web" || pod.UID == "" || pod.ResourceVersion == "" {
+		t.Errorf("expected created pod; got: %v", pod.ObjectMeta)
+	}
+
+	stale := pod.DeepCopy()
+	pod.Labels["app"] = "db"
+	if err := client.Update(pod); err != nil {
+		t.Fatalf("failed to update pod: %v", err)
+	}
+	if err := client.Update(stale); !apierrors.IsConflict(err) {
+		t.Errorf("expected conflict error; got: %v", err)
+	}
+
+	if err := client.Patch(pod, types.MergePatchType, []byte(`{"metadata":{"labels":{"tier":"backend"}}}`)); err != nil {
+		t.Fatalf("failed to patch pod: %v", err)
+	}
+	if pod.Labels["tier"] != "backend" || pod.Labels["app"] != "db" {
+		t.Errorf("expected patched labels; got: %v", pod.Labels)
+	}
+	if err := client.Patch(pod, types.JSONPatchType, []byte(`[{"op":"remove","path":"/metadata/labels/tier"}]`)); err != nil {
+		t.Fatalf("failed to patch pod: %v", err)
+	}
+	patched := newTypedPod("a", "")
+	if err := client.Get(patched); err != nil {
+		t.Fatalf("failed to get pod: %v", err)
+	}
+	if _, ok := patched.Labels["tier"]; ok || patched.Labels["app"] != "db" {
+		t.Errorf("expected the tier label to be removed; got: %v", patched.Labels)
+	}
+
+	// Only the status is updated through the status subresource.
+	pod.Status.Phase = corev1.PodRunning
+	pod.Labels["app"] = "web"
+	if err := client.UpdateStatus(pod); err != nil {
+		t.Fatalf("failed to update pod status: %v", err)
+	}
+	if pod.Status.Phase != corev1.PodRunning || pod.Labels["app"] != "db" {
+		t.Errorf("expected only the status to be updated; got: %v, %v", pod.Status.Phase, pod.Labels)
+	}
+
+	pods := &corev1.PodList{TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"}}
+	if err := client.List("ns1", pods, WithListOptions(&metav1.ListOptions{LabelSelector: "app=db"})); err != nil {
+		t.Fatalf("failed to list pods: %v", err)
+	}
+	if len(pods.Items) != 2 {
+		t.Errorf("expected 2 pods; got: %d", len(pods.Items))
+	}
+
+	if err := client.Delete(pod); err != nil {
+		t.Fatalf("failed to delete pod: %v", err)
+	}
+	if err := client.Get(pod); !apierrors.IsNotFound(err) {
+		t.Errorf("expected not found error; got: %v", err)
+	}
+}
@@ -14,7 +14,10 @@
 
 package sdk
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // Handler reacts to events and outputs actions.
 // If any intended action failed, the event would be re-triggered.
@@ -23,7 +26,61 @@ type Handler interface {
 	Handle(context.Context, Event) error
 }
 
+// Result tells the informer when to handle the event's object again.
+// The zero Result handles the object again only on its next event.
+// Requeuing with a Result does not count as a retry of a failed event.
+type Result struct {
+	// Requeue handles the object again right away.
+	Requeue bool
+	// RequeueAfter handles the object again after the given duration.
+	// It takes precedence over Requeue.
+	RequeueAfter time.Duration
+}
+
+func (r Result) requeues() bool {
+	return r.Requeue || r.RequeueAfter > 0
+}
+
+// ResultHandler reacts to events like a Handler, and also returns
+// a Result, e.g to poll external state without returning an error.
+// If an error is returned, the Result is ignored and the event is re-triggered.
+type ResultHandler interface {
+	HandleResult(context.Context, Event) (Result, error)
+}
+
+// ResultHandlerFunc is an adapter to allow the use of ordinary functions as a ResultHandler.
+type ResultHandlerFunc func(context.Context, Event) (Result, error)
+
+// HandleResult calls f(ctx, event).
+func (f ResultHandlerFunc) HandleResult(ctx context.Context, event Event) (Result, error) {
+	return f(ctx, event)
+}
+
+// handlerAdapter adapts a Handler into a ResultHandler that
+// always returns the zero Result.
+type handlerAdapter struct {
+	handler Handler
+}
+
+func (a handlerAdapter) HandleResult(ctx context.Context, event Event) (Result, error) {
+	return Result{}, a.handler.Handle(ctx, event)
+}
+
+// toResultHandler returns the handler itself if it is a ResultHandler,
+// or adapts it otherwise.
+func toResultHandler(handler Handler) ResultHandler {
+	if rh, ok := handler.(ResultHandler); ok {
+		return rh
+	}
+	return handlerAdapter{handler: handler}
+}
+
 var (
 	// RegisteredHandler is the user registered handler set by sdk.Handle()
+	// and the other sdk.Handle*() functions. It is DefaultMux unless set directly.
+	// If it is also a ResultHandler, its HandleResult() is called instead of Handle().
 	RegisteredHandler Handler
+
+	// DefaultMux is the Mux used by the sdk.Handle*() functions.
+	DefaultMux = NewMux()
 )
@@ -0,0 +1,142 @@
+// Copyright 2018 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	healthzPath = "/healthz"
+	readyzPath  = "/readyz"
+)
+
+// HealthCheck returns an error if the operator is not healthy or not ready.
+type HealthCheck func() error
+
+var (
+	checksMu     sync.RWMutex
+	healthChecks = map[string]HealthCheck{}
+	readyChecks  = map[string]HealthCheck{
+		"informers-synced": informersSynced,
+		"leader":           isLeaderIfElected,
+	}
+
+	// leaderState tells whether Run was passed WithLeaderElection, and if it became the leader.
+	leaderState int32
+)
+
+const (
+	leaderNotElected int32 = iota
+	leaderWaiting
+	leaderElected
+)
+
+// AddHealthCheck registers a check for the /healthz endpoint, which is served by ExposeMetricsPort
+// and used as the liveness probe of the operator. A failing check gets the operator restarted.
+// Registering a check with the name of a registered check replaces it.
+func AddHealthCheck(name string, check HealthCheck) {
+	checksMu.Lock()
+	defer checksMu.Unlock()
+	healthChecks[name] = check
+}
+
+// AddReadyCheck registers a check for the /readyz endpoint, which is served by ExposeMetricsPort
+// and used as the readiness probe of the operator.
+// The operator is only ready once the caches of all watches are synced and, if Run was passed
+// WithLeaderElection, it is the leader.
+// Registering a check with the name of a registered check replaces it.
+func AddReadyCheck(name string, check HealthCheck) {
+	checksMu.Lock()
+	defer checksMu.Unlock()
+	readyChecks[name] = check
+}
+
+func healthzHandler() http.Handler {
+	return checksHandler(healthChecks)
+}
+
+func readyzHandler() http.Handler {
+	return checksHandler(readyChecks)
+}
+
+// checksHandler runs the checks and responds with the result of each of them.
+// The status is 200 if all checks pass, and 500 otherwise.
+func checksHandler(checks map[string]HealthCheck) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The checks run without the lock, so that a slow check does not block
+		// the registration of checks, nor a check that registers one.
+		checksMu.RLock()
+		names := make([]string, 0, len(checks))
+		copied := make(map[string]HealthCheck, len(checks))
+		for name, check := range checks {
+			names = append(names, name)
+			copied[name] = check
+		}
+		checksMu.RUnlock()
+		sort.Strings(names)
+		var failed bool
+		var body string
+		for _, name := range names {
+			if err := copied[name](); err != nil {
+				failed = true
+				body += fmt.Sprintf("[-]%s failed: %v\n", name, err)
+				continue
+			}
+			body += fmt.Sprintf("[+]%s ok\n", name)
+		}
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		if failed {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, body+"check failed\n")
+			return
+		}
+		fmt.Fprint(w, body+"ok\n")
+	})
+}
+
+// informersSynced returns an error if the cache of a watch is not synced yet,
+// or a watch is still waiting for its kind.
+func informersSynced() error {
+	informersMu.RLock()
+	defer informersMu.RUnlock()
+	if len(waiters) > 0 {
+		return fmt.Errorf("%d watches waiting for their kind", len(waiters))
+	}
+	for _, i := range informers {
+		if i.namespaceInformer != nil && !i.namespaceInformer.HasSynced() {
+			return fmt.Errorf("namespaces for %s in namespace (%s) not synced", i.resourcePluralName, i.namespace)
+		}
+		if !i.sharedIndexInformer.HasSynced() {
+			return fmt.Errorf("%s in namespace (%s) not synced", i.resourcePluralName, i.namespace)
+		}
+	}
+	return nil
+}
+
+// isLeaderIfElected returns an error if Run was passed WithLeaderElection
+// and the operator did not become the leader yet.
+func isLeaderIfElected() error {
+	if atomic.LoadInt32(&leaderState) == leaderWaiting {
+		return fmt.Errorf("not the leader")
+	}
+	return nil
+}
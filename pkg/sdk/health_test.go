@@ -0,0 +1,105 @@
+// Copyright 2018 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestChecksHandler(t *testing.T) {
+	type Scenario struct {
+		name           string
+		checks         map[string]HealthCheck
+		expectedStatus int
+		expectedBody   string
+	}
+
+	tests := []Scenario{
+		Scenario{
+			name:           "No checks",
+			checks:         map[string]HealthCheck{},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "ok\n",
+		},
+		Scenario{
+			name: "Passing checks",
+			checks: map[string]HealthCheck{
+				"b": func() error { return nil },
+				"a": func() error { return nil },
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "[+]a ok\n[+]b ok\nok\n",
+		},
+		Scenario{
+			name: "Failing check",
+			checks: map[string]HealthCheck{
+				"a": func() error { return nil },
+				"b": func() error { return errors.New("boom") },
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   "[+]a ok\n[-]b failed: boom\ncheck failed\n",
+		},
+		Scenario{
+			name: "Check that registers a check",
+			checks: map[string]HealthCheck{
+				"a": func() error {
+					AddReadyCheck("registered", func() error { return nil })
+					return nil
+				},
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "[+]a ok\nok\n",
+		},
+	}
+	defer func() {
+		checksMu.Lock()
+		delete(readyChecks, "registered")
+		checksMu.Unlock()
+	}()
+
+	for _, test := range tests {
+		rec := httptest.NewRecorder()
+		checksHandler(test.checks).ServeHTTP(rec, httptest.NewRequest("GET", readyzPath, nil))
+		if rec.Code != test.expectedStatus {
+			t.Errorf("test %s failed, expected status: %d; got: %d", test.name, test.expectedStatus, rec.Code)
+		}
+		if rec.Body.String() != test.expectedBody {
+			t.Errorf("test %s failed, expected body: %q; got: %q", test.name, test.expectedBody, rec.Body.String())
+		}
+	}
+}
+
+func TestReadyChecks(t *testing.T) {
+	informers = []*informer{newTestInformer(t, "ns1")}
+	defer func() { informers = nil }()
+	if err := informersSynced(); err == nil || !strings.Contains(err.Error(), "not synced") {
+		t.Errorf("expected not synced error; got: %v", err)
+	}
+
+	atomic.StoreInt32(&leaderState, leaderWaiting)
+	defer atomic.StoreInt32(&leaderState, leaderNotElected)
+	if err := isLeaderIfElected(); err == nil {
+		t.Error("expected an error while waiting to become the leader")
+	}
+	atomic.StoreInt32(&leaderState, leaderElected)
+	if err := isLeaderIfElected(); err != nil {
+		t.Errorf("expected no error for the leader; got: %v", err)
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright 2018 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// queuedEvent is the change to an object since its key was queued.
+type queuedEvent struct {
+	eventType EventType
+	// oldObj is the state of the object before an update.
+	oldObj *unstructured.Unstructured
+}
+
+// mergeEvents returns the event for the changes in prev followed by the changes in next.
+func mergeEvents(prev, next queuedEvent) queuedEvent {
+	switch {
+	case next.eventType == EventTypeDelete:
+		return next
+	case prev.eventType == EventTypeDelete:
+		// The object was recreated.
+		return queuedEvent{eventType: EventTypeAdd}
+	case prev.eventType == EventTypeAdd, prev.eventType == EventTypeUpdate:
+		// Keep the state from before the first update.
+		return prev
+	default:
+		return next
+	}
+}
+
+// recordEvent merges the event into the event recorded for the key.
+// It must be called before the key is added to the queue.
+func (i *informer) recordEvent(key string, event queuedEvent) {
+	i.queuedEventsMu.Lock()
+	defer i.queuedEventsMu.Unlock()
+	if prev, ok := i.queuedEvents[key]; ok {
+		event = mergeEvents(prev, event)
+	}
+	i.queuedEvents[key] = event
+}
+
+// takeEvent removes and returns the event recorded for the key.
+// Keys that were queued without a change to the object, e.g by a requeue, get an EventTypeResync event.
+func (i *informer) takeEvent(key string) queuedEvent {
+	i.queuedEventsMu.Lock()
+	defer i.queuedEventsMu.Unlock()
+	event, ok := i.queuedEvents[key]
+	if !ok {
+		return queuedEvent{eventType: EventTypeResync}
+	}
+	delete(i.queuedEvents, key)
+	return event
+}
+
+// restoreEvent records the event for the key again, before any event recorded since it was taken,
+// so that a retry of a failed key is handled with the same event type and old object.
+func (i *informer) restoreEvent(key string, event queuedEvent) {
+	i.queuedEventsMu.Lock()
+	defer i.queuedEventsMu.Unlock()
+	if next, ok := i.queuedEvents[key]; ok {
+		event = mergeEvents(event, next)
+	}
+	i.queuedEvents[key] = event
+}
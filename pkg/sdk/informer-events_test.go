@@ -0,0 +1,91 @@
+// Copyright 2018 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestRecordEvent(t *testing.T) {
+	v1 := newTestPod("ns1", "a", "web", "uid-1")
+	v2 := newTestPod("ns1", "a", "db", "uid-1")
+
+	type Scenario struct {
+		name         string
+		events       []queuedEvent
+		expectedType EventType
+		expectedOld  *unstructured.Unstructured
+	}
+
+	tests := []Scenario{
+		Scenario{
+			name:         "No event",
+			expectedType: EventTypeResync,
+		},
+		Scenario{
+			name:         "Add then update",
+			events:       []queuedEvent{{eventType: EventTypeAdd}, {eventType: EventTypeUpdate, oldObj: v1}},
+			expectedType: EventTypeAdd,
+		},
+		Scenario{
+			name:         "Updates keep the first old object",
+			events:       []queuedEvent{{eventType: EventTypeUpdate, oldObj: v1}, {eventType: EventTypeUpdate, oldObj: v2}},
+			expectedType: EventTypeUpdate,
+			expectedOld:  v1,
+		},
+		Scenario{
+			name:         "Resync then update",
+			events:       []queuedEvent{{eventType: EventTypeResync}, {eventType: EventTypeUpdate, oldObj: v2}},
+			expectedType: EventTypeUpdate,
+			expectedOld:  v2,
+		},
+		Scenario{
+			name:         "Update then delete",
+			events:       []queuedEvent{{eventType: EventTypeUpdate, oldObj: v1}, {eventType: EventTypeDelete}},
+			expectedType: EventTypeDelete,
+		},
+		Scenario{
+			name:         "Delete then add",
+			events:       []queuedEvent{{eventType: EventTypeDelete}, {eventType: EventTypeAdd}},
+			expectedType: EventTypeAdd,
+		},
+	}
+
+	for _, test := range tests {
+		i := &informer{queuedEvents: map[string]queuedEvent{}}
+		for _, event := range test.events {
+			i.recordEvent("ns1/a", event)
+		}
+		event := i.takeEvent("ns1/a")
+		if event.eventType != test.expectedType {
+			t.Errorf("test %s failed, expected type: %s; got: %s", test.name, test.expectedType, event.eventType)
+		}
+		if event.oldObj != test.expectedOld {
+			t.Errorf("test %s failed, expected old object: %v; got: %v", test.name, test.expectedOld, event.oldObj)
+		}
+	}
+
+	// A failed event is restored before the events recorded since.
+	i := &informer{queuedEvents: map[string]queuedEvent{}}
+	i.recordEvent("ns1/a", queuedEvent{eventType: EventTypeUpdate, oldObj: v1})
+	failed := i.takeEvent("ns1/a")
+	i.recordEvent("ns1/a", queuedEvent{eventType: EventTypeUpdate, oldObj: v2})
+	i.restoreEvent("ns1/a", failed)
+	if event := i.takeEvent("ns1/a"); event.oldObj != v1 {
+		t.Errorf("expected old object: %v; got: %v", v1, event.oldObj)
+	}
+}
@@ -15,6 +15,9 @@
 package sdk
 
 import (
+	"context"
+	"time"
+
 	"github.com/operator-framework/operator-sdk/pkg/sdk/internal/metrics"
 	"github.com/operator-framework/operator-sdk/pkg/util/k8sutil"
 
@@ -22,16 +25,6 @@ import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
-const (
-	// Copy from deployment_controller.go:
-	// maxRetries is the number of times a Vault will be retried before it is dropped out of the queue.
-	// With the current rate-limiter in use (5ms*2^(maxRetries-1)) the following numbers represent the times
-	// a Vault is going to be requeued:
-	//
-	// 5ms, 10ms, 20ms, 40ms, 80ms, 160ms, 320ms, 640ms, 1.3s, 2.6s, 5.1s, 10.2s, 20.4s, 41s, 82s
-	maxRetries = 15
-)
-
 func (i *informer) runWorker() {
 	for i.processNextItem() {
 	}
@@ -48,77 +41,177 @@ func (i *informer) processNextItem() bool {
 	// parallel.
 	defer i.queue.Done(key)
 
+	// Leave the rest of the queue unhandled once a graceful shutdown started.
+	if i.isStopping() {
+		i.addPending(key.(string))
+		return true
+	}
+
 	// Invoke the method containing the business logic
-	err := i.sync(key.(string))
+	result, err := i.sync(key.(string))
 
-	// Handle the error if something went wrong during the execution of the business logic
-	i.handleErr(err, key)
+	// Requeue the key as requested, or handle the error if something went wrong
+	// during the execution of the business logic
+	i.handleResult(result, err, key)
 	return true
 }
 
 // sync creates the event for the object and sends it to the handler
-func (i *informer) sync(key string) error {
+func (i *informer) sync(key string) (result Result, err error) {
+	queued := i.takeEvent(key)
+	// Keep the event for the retry of a failed key.
+	defer func() {
+		if err != nil {
+			i.restoreEvent(key, queued)
+		}
+	}()
+
 	obj, exists, err := i.sharedIndexInformer.GetIndexer().GetByKey(key)
 	if err != nil {
-		return err
+		return Result{}, err
 	}
 	if !exists {
 		logrus.Debugf("Object (%s) is deleted", key)
 		// Lookup the last saved state for the deleted object
-		_, ok := i.deletedObjects[key]
+		var ok bool
+		obj, ok = i.tombstones.get(key)
 		if !ok {
 			logrus.Errorf("no last known state found for deleted object (%s)", key)
-			return nil
+			return Result{}, nil
 		}
-		obj = i.deletedObjects[key]
+	} else {
+		// The object may have been recreated before its deletion was handled.
+		i.tombstones.remove(key)
 	}
 
-	unstructObj := obj.(*unstructured.Unstructured).DeepCopy()
-	object, err := k8sutil.RuntimeObjectFromUnstructured(unstructObj)
+	object, err := toObject(obj.(*unstructured.Unstructured))
 	if err != nil {
-		return err
+		return Result{}, err
 	}
-
 	event := Event{
+		Type:    queued.eventType,
 		Object:  object,
 		Deleted: !exists,
+		Cluster: i.cluster,
+	}
+	if !exists {
+		event.Type = EventTypeDelete
+	} else if queued.eventType == EventTypeDelete {
+		// The object was recreated before the deletion was handled.
+		event.Type = EventTypeAdd
+	}
+	if event.Type == EventTypeUpdate && queued.oldObj != nil {
+		if event.OldObject, err = toObject(queued.oldObj); err != nil {
+			return Result{}, err
+		}
 	}
 
-	// TODO: Add option to prevent multiple informers from invoking Handle() concurrently?
-	err = RegisteredHandler.Handle(i.context, event)
-	if !exists && err == nil {
-		delete(i.deletedObjects, key)
+	i.callLock.lock(key)
+	// The timeout starts once the handler owns the key, so that waiting for
+	// the call of another watch on the same object does not count against it.
+	ctx := i.context
+	if i.cluster != "" {
+		ctx = ContextWithCluster(ctx, i.cluster)
+	}
+	if i.reconcileTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, i.reconcileTimeout)
+		defer cancel()
+	}
+	start := time.Now()
+	result, err = toResultHandler(RegisteredHandler).HandleResult(ctx, event)
+	i.collector.ReconcileDuration.WithLabelValues(i.resourcePluralName).Observe(time.Since(start).Seconds())
+	i.callLock.unlock(key)
+	// Keep the last known state of a deleted object until it is no longer requeued
+	if !exists && err == nil && !result.requeues() {
+		i.tombstones.remove(key)
 	}
 	switch {
 	case err == nil:
-		i.collector.ReconcileResult.WithLabelValues(metrics.ReconcileResultSuccess).Inc()
+		i.collector.ReconcileResult.WithLabelValues(i.resourcePluralName, metrics.ReconcileResultSuccess).Inc()
 	case err != nil:
-		i.collector.ReconcileResult.WithLabelValues(metrics.ReconcileResultFailure).Inc()
+		i.collector.ReconcileResult.WithLabelValues(i.resourcePluralName, metrics.ReconcileResultFailure).Inc()
 	}
-	return err
+	return result, err
 }
 
-// handleErr checks if an error happened and makes sure we will retry later.
-func (i *informer) handleErr(err error, key interface{}) {
+// handleResult requeues the key as requested by the result, or
+// checks if an error happened and makes sure we will retry later.
+func (i *informer) handleResult(result Result, err error, key interface{}) {
 	if err == nil {
 		// Forget about the #AddRateLimited history of the key on every successful synchronization.
 		// This ensures that future processing of updates for this key is not delayed because of
 		// an outdated error history.
 		i.queue.Forget(key)
+		switch {
+		case result.RequeueAfter > 0:
+			i.requeue(key, func() { i.queue.AddAfter(key, result.RequeueAfter) })
+		case result.Requeue:
+			i.requeue(key, func() { i.queue.Add(key) })
+		}
+		return
+	}
+
+	// Retrying won't help an event that no handler is registered for.
+	if IsNoRouteError(err) {
+		i.queue.Forget(key)
+		i.takeEvent(key.(string))
+		i.tombstones.remove(key.(string))
+		logrus.Errorf("Dropping key (%v) out of the queue: %v", key, err)
 		return
 	}
 
 	// This controller retries maxRetries times if something goes wrong. After that, it stops trying.
-	if i.queue.NumRequeues(key) < maxRetries {
+	if i.maxRetries == UnlimitedRetries || i.queue.NumRequeues(key) < i.maxRetries {
 		logrus.Errorf("error syncing key (%v): %v", key, err)
 
 		// Re-enqueue the key rate limited. Based on the rate limiter on the
 		// queue and the re-enqueue history, the key will be processed later again.
-		i.queue.AddRateLimited(key)
+		i.requeue(key, func() { i.queue.AddRateLimited(key) })
 		return
 	}
 
-	i.queue.Forget(key)
 	// Report that, even after several retries, we could not successfully process this key
 	logrus.Warnf("Dropping key (%v) out of the queue: %v", key, err)
+	i.recordDropped(key.(string), err)
+	i.queue.Forget(key)
+	i.takeEvent(key.(string))
+	i.tombstones.remove(key.(string))
+	if i.deadLetterFunc != nil {
+		i.deadLetterFunc(key.(string), err)
+	}
+}
+
+// requeue calls add to requeue the key. Once a graceful shutdown started, the queue is shut
+// down and ignores the key, which is recorded as pending instead.
+func (i *informer) requeue(key interface{}, add func()) {
+	if i.isStopping() {
+		i.addPending(key.(string))
+		return
+	}
+	add()
+}
+
+// recordDropped records a Warning event on the object of a key that is dropped out of the queue,
+// in the cluster of the watch. Nothing is recorded for a deleted object.
+func (i *informer) recordDropped(key string, err error) {
+	obj, exists, getErr := i.sharedIndexInformer.GetIndexer().GetByKey(key)
+	if getErr != nil || !exists {
+		return
+	}
+	RecorderFromContext(ContextWithCluster(i.context, i.cluster)).Warning(obj.(*unstructured.Unstructured), reasonReconcileFailed,
+		"Failed to reconcile after %d retries: %v", i.queue.NumRequeues(key), err)
+}
+
+// toObject decodes a copy of the unstructured object into the runtime object for its kind.
+func toObject(u *unstructured.Unstructured) (Object, error) {
+	u = u.DeepCopy()
+	object, err := k8sutil.RuntimeObjectFromUnstructured(u)
+	if err != nil {
+		return nil, err
+	}
+	// The decoder may drop the TypeMeta, which handlers and DefaultMux rely on
+	// to tell the event object's kind.
+	object.GetObjectKind().SetGroupVersionKind(u.GroupVersionKind())
+	return object, nil
 }
@@ -0,0 +1,189 @@
+// Copyright 2018 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/operator-framework/operator-sdk/pkg/sdk/internal/metrics"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
+)
+
+// countingRateLimiter requeues right away and counts the requeues of each key.
+type countingRateLimiter struct {
+	requeues map[interface{}]int
+}
+
+func (r *countingRateLimiter) When(item interface{}) time.Duration {
+	r.requeues[item]++
+	return 0
+}
+
+func (r *countingRateLimiter) Forget(item interface{}) {
+	delete(r.requeues, item)
+}
+
+func (r *countingRateLimiter) NumRequeues(item interface{}) int {
+	return r.requeues[item]
+}
+
+func TestHandleResult(t *testing.T) {
+	type Scenario struct {
+		name string
+		opts []watchOption
+		// failures is the number of times the handler fails for the key.
+		failures int
+		// succeed makes the handler succeed for the key after the failures.
+		succeed            bool
+		expectedRequeues   int
+		expectedDeadLetter string
+	}
+
+	scenarios := []Scenario{
+		{
+			name:               "drop after max retries",
+			opts:               []watchOption{WithMaxRetries(2)},
+			failures:           3,
+			expectedRequeues:   0,
+			expectedDeadLetter: "ns1/a: boom 3",
+		},
+		{
+			name:             "retry until max retries",
+			opts:             []watchOption{WithMaxRetries(2)},
+			failures:         2,
+			expectedRequeues: 2,
+		},
+		{
+			name:             "never drop with unlimited retries",
+			opts:             []watchOption{WithMaxRetries(UnlimitedRetries)},
+			failures:         20,
+			expectedRequeues: 20,
+		},
+		{
+			name:             "forget the retries on success",
+			failures:         2,
+			succeed:          true,
+			expectedRequeues: 0,
+		},
+	}
+
+	for _, s := range scenarios {
+		limiter := &countingRateLimiter{requeues: map[interface{}]int{}}
+		var deadLetter string
+		opts := append([]watchOption{
+			WithRateLimiter(limiter),
+			WithDeadLetterFunc(func(key string, err error) {
+				deadLetter += fmt.Sprintf("%s: %v", key, err)
+			}),
+		}, s.opts...)
+		o := newWatchOp()
+		o.applyOpts(opts)
+		i := newInformer("v1", "Pod", "pods", metav1.NamespaceAll, nil, 0, metrics.New(), o)
+		i.context = ContextWithRecorder(context.TODO(), NewRecorder(record.NewFakeRecorder(10)))
+
+		for n := 1; n <= s.failures; n++ {
+			i.handleResult(Result{}, fmt.Errorf("boom %d", n), "ns1/a")
+		}
+		if s.succeed {
+			i.handleResult(Result{}, nil, "ns1/a")
+		}
+		i.queue.ShutDown()
+
+		// The queue requeues through the rate limiter set by WithRateLimiter.
+		if n := limiter.NumRequeues("ns1/a"); n != s.expectedRequeues {
+			t.Errorf("test %s failed, expected requeues: %d; got: %d", s.name, s.expectedRequeues, n)
+		}
+		if deadLetter != s.expectedDeadLetter {
+			t.Errorf("test %s failed, expected dead letter: %q; got: %q", s.name, s.expectedDeadLetter, deadLetter)
+		}
+	}
+}
+
+func TestHandleResultRequeues(t *testing.T) {
+	type Scenario struct {
+		name   string
+		result Result
+		// expectedDelay is the minimum time until the key is requeued.
+		expectedDelay time.Duration
+	}
+
+	scenarios := []Scenario{
+		{
+			name:   "requeue right away",
+			result: Result{Requeue: true},
+		},
+		{
+			name:          "requeue after a duration",
+			result:        Result{RequeueAfter: 50 * time.Millisecond},
+			expectedDelay: 50 * time.Millisecond,
+		},
+	}
+
+	for _, s := range scenarios {
+		i := newInformer("v1", "Pod", "pods", metav1.NamespaceAll, nil, 0, metrics.New(), newWatchOp())
+		start := time.Now()
+		i.handleResult(s.result, nil, "ns1/a")
+
+		keys := make(chan interface{}, 1)
+		go func() {
+			key, _ := i.queue.Get()
+			keys <- key
+		}()
+		select {
+		case key := <-keys:
+			if key != "ns1/a" {
+				t.Errorf("test %s failed, expected requeued key: ns1/a; got: %v", s.name, key)
+			}
+			if elapsed := time.Since(start); elapsed < s.expectedDelay {
+				t.Errorf("test %s failed, expected requeue after: %v; got: %v", s.name, s.expectedDelay, elapsed)
+			}
+		case <-time.After(wait.ForeverTestTimeout):
+			t.Errorf("test %s failed, expected the key to be requeued", s.name)
+		}
+		i.queue.ShutDown()
+	}
+}
+
+func TestSyncWithCluster(t *testing.T) {
+	defer func() { RegisteredHandler = nil }()
+	var cluster string
+	RegisteredHandler = HandlerFunc(func(ctx context.Context, event Event) error {
+		cluster, _ = ctx.Value(clusterKey{}).(string)
+		return nil
+	})
+
+	o := newWatchOp()
+	o.applyOpts([]watchOption{WithCluster("dev")})
+	i := newInformer("v1", "Pod", "pods", metav1.NamespaceAll, nil, 0, metrics.New(), o)
+	defer i.queue.ShutDown()
+	i.context = context.TODO()
+	if err := i.sharedIndexInformer.GetIndexer().Add(newTestPod("ns1", "a", "web", "uid-1")); err != nil {
+		t.Fatalf("failed to add pod: %v", err)
+	}
+
+	// The handler's context targets the cluster of the watch, e.g for ClientFromContext.
+	if _, err := i.sync("ns1/a"); err != nil {
+		t.Fatalf("failed to sync: %v", err)
+	}
+	if cluster != "dev" {
+		t.Errorf("expected the handler's context to target cluster: dev; got: %q", cluster)
+	}
+}
@@ -16,6 +16,8 @@ package sdk
 
 import (
 	"context"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/operator-framework/operator-sdk/pkg/sdk/internal/metrics"
@@ -23,6 +25,7 @@ import (
 	"github.com/sirupsen/logrus"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/apimachinery/pkg/watch"
@@ -36,28 +39,89 @@ type Informer interface {
 }
 
 type informer struct {
+	apiVersion          string
+	kind                string
 	resourcePluralName  string
+	labelSelector       string
+	fieldSelector       string
 	sharedIndexInformer cache.SharedIndexInformer
 	queue               workqueue.RateLimitingInterface
 	namespace           string
 	context             context.Context
-	deletedObjects      map[string]interface{}
+	tombstones          *tombstoneStore
 	collector           *metrics.Collector
 	numWorkers          int
+	maxRetries          int
+	deadLetterFunc      DeadLetterFunc
+	ownerAPIVersion     string
+	ownerKind           string
+	// namespaceInformer caches the namespaces to filter events by namespaceSelector.
+	namespaceInformer *namespaceInformer
+	namespaceSelector labels.Selector
+	predicates        []Predicate
+	reconcileTimeout  time.Duration
+	// cluster is the name of the cluster of the watch, empty for the default cluster.
+	cluster string
+
+	// queuedEvents holds the changes to the queued keys, so that the queue
+	// only has to hold the keys. See recordEvent.
+	queuedEventsMu sync.Mutex
+	queuedEvents   map[string]queuedEvent
+
+	// workers tracks the running workers so that shutdown can wait for them.
+	workers sync.WaitGroup
+	// stopping is set once a graceful shutdown started. Workers then stop
+	// handling keys and record them in pending instead.
+	stopping  int32
+	pendingMu sync.Mutex
+	pending   []string
+
+	// stop stops the informer started by Run, and cancelHandlers cancels the context of
+	// its handlers. done is closed once the informer stopped. See startInformer.
+	stop           context.CancelFunc
+	cancelHandlers context.CancelFunc
+	done           chan struct{}
+	// callLock serializes the calls into the handler, it is set by startInformer.
+	callLock handlerLock
+	// removed is set once the watch of the informer is stopped, see WatchHandle.Stop.
+	removed int32
 }
 
 func NewInformer(resourcePluralName, namespace string, resourceClient dynamic.ResourceInterface, resyncPeriod time.Duration, c *metrics.Collector, n int, labelSelector string) Informer {
+	o := newWatchOp()
+	o.applyOpts([]watchOption{WithNumWorkers(n), WithLabelSelector(labelSelector)})
+	return newInformer("", "", resourcePluralName, namespace, resourceClient, resyncPeriod, c, o)
+}
+
+func newInformer(apiVersion, kind, resourcePluralName, namespace string, resourceClient dynamic.ResourceInterface, resyncPeriod time.Duration, c *metrics.Collector, o *watchOp) *informer {
 	i := &informer{
+		apiVersion:         apiVersion,
+		kind:               kind,
 		resourcePluralName: resourcePluralName,
-		queue:              workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), resourcePluralName),
+		labelSelector:      o.labelSelector,
+		fieldSelector:      o.fieldSelector,
+		queue:              workqueue.NewNamedRateLimitingQueue(o.rateLimiter, resourcePluralName),
 		namespace:          namespace,
-		deletedObjects:     map[string]interface{}{},
+		tombstones:         newTombstoneStore(o.tombstoneTTL, o.maxTombstones, c.Tombstones.WithLabelValues(resourcePluralName)),
+		queuedEvents:       map[string]queuedEvent{},
 		collector:          c,
-		numWorkers:         n,
+		numWorkers:         o.numWorkers,
+		maxRetries:         o.maxRetries,
+		deadLetterFunc:     o.deadLetterFunc,
+		ownerAPIVersion:    o.ownerAPIVersion,
+		ownerKind:          o.ownerKind,
+		predicates:         o.predicates,
+		reconcileTimeout:   o.reconcileTimeout,
+		cluster:            o.cluster,
+		callLock:           noLock{},
 	}
 
+	indexers := cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}
+	for name, indexFunc := range o.indexers {
+		indexers[name] = indexFunc
+	}
 	i.sharedIndexInformer = cache.NewSharedIndexInformer(
-		newListWatcherFromResourceClient(resourceClient, labelSelector), &unstructured.Unstructured{}, resyncPeriod, cache.Indexers{},
+		newListWatcherFromResourceClient(resourceClient, o.labelSelector, o.fieldSelector, i.recordListed), &unstructured.Unstructured{}, resyncPeriod, indexers,
 	)
 	i.sharedIndexInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc:    i.handleAddResourceEvent,
@@ -67,70 +131,189 @@ func NewInformer(resourcePluralName, namespace string, resourceClient dynamic.Re
 	return i
 }
 
-func newListWatcherFromResourceClient(resourceClient dynamic.ResourceInterface, labelSelector string) *cache.ListWatch {
+// newListWatcherFromResourceClient returns a ListWatch for the resource client.
+// onList, if not nil, is called after each successful list.
+func newListWatcherFromResourceClient(resourceClient dynamic.ResourceInterface, labelSelector, fieldSelector string, onList func()) *cache.ListWatch {
 	listFunc := func(options metav1.ListOptions) (runtime.Object, error) {
 		if labelSelector != "" {
 			options.LabelSelector = labelSelector
 		}
-		return resourceClient.List(options)
+		if fieldSelector != "" {
+			options.FieldSelector = fieldSelector
+		}
+		list, err := resourceClient.List(options)
+		if err == nil && onList != nil {
+			onList()
+		}
+		return list, err
 	}
 	watchFunc := func(options metav1.ListOptions) (watch.Interface, error) {
 		if labelSelector != "" {
 			options.LabelSelector = labelSelector
 		}
+		if fieldSelector != "" {
+			options.FieldSelector = fieldSelector
+		}
 		return resourceClient.Watch(options)
 	}
 	return &cache.ListWatch{ListFunc: listFunc, WatchFunc: watchFunc}
 }
 
+// recordListed records the time of the last full list of the watch from the API server.
+func (i *informer) recordListed() {
+	i.collector.LastSync.WithLabelValues(i.resourcePluralName, i.namespace).SetToCurrentTime()
+}
+
 func (i *informer) Run(ctx context.Context) {
-	i.context = ctx
+	i.run(ctx, ctx, false)
+}
+
+// run starts the informer and its workers and blocks until ctx is done.
+// Handlers are invoked with handlerCtx.
+// If drain is true, run then stops handling new keys and waits for the
+// in-flight handlers to return. Keys left in the queue, and those the in-flight
+// handlers requeue, are recorded as pending.
+func (i *informer) run(ctx, handlerCtx context.Context, drain bool) {
+	i.context = handlerCtx
 	defer i.queue.ShutDown()
 
 	logrus.Debugf("starting %s controller", i.resourcePluralName)
+	// The namespaces must be cached before the first events for objects are filtered.
+	if i.namespaceInformer != nil {
+		defer i.namespaceInformer.release()
+		if !i.namespaceInformer.start(ctx) {
+			if ctx.Err() != nil {
+				return
+			}
+			panic("Timed out waiting for namespace cache to sync")
+		}
+	}
 	go i.sharedIndexInformer.Run(ctx.Done())
 
 	if !cache.WaitForCacheSync(ctx.Done(), i.sharedIndexInformer.HasSynced) {
+		if ctx.Err() != nil {
+			return
+		}
 		panic("Timed out waiting for caches to sync")
 	}
 
 	for n := 0; n < i.numWorkers; n++ {
-		go wait.Until(i.runWorker, time.Second, ctx.Done())
+		i.workers.Add(1)
+		go func() {
+			defer i.workers.Done()
+			wait.Until(i.runWorker, time.Second, ctx.Done())
+		}()
 	}
 	<-ctx.Done()
 	logrus.Debugf("stopping %s controller", i.resourcePluralName)
+
+	if atomic.LoadInt32(&i.removed) == 1 {
+		// The workers handle the keys left in the queue once it is shut down.
+		i.queue.ShutDown()
+		i.workers.Wait()
+		logrus.Debugf("drained %s controller of a stopped watch", i.resourcePluralName)
+		return
+	}
+	if drain {
+		atomic.StoreInt32(&i.stopping, 1)
+		i.queue.ShutDown()
+		i.workers.Wait()
+		logrus.Debugf("drained %s controller", i.resourcePluralName)
+	}
+}
+
+func (i *informer) isStopping() bool {
+	return atomic.LoadInt32(&i.stopping) == 1
+}
+
+func (i *informer) addPending(key string) {
+	i.pendingMu.Lock()
+	defer i.pendingMu.Unlock()
+	i.pending = append(i.pending, key)
+}
+
+// pendingKeys returns the keys that were still queued or requeued when the informer stopped.
+func (i *informer) pendingKeys() []string {
+	i.pendingMu.Lock()
+	defer i.pendingMu.Unlock()
+	return append([]string(nil), i.pending...)
 }
 
 func (i *informer) handleAddResourceEvent(obj interface{}) {
+	if !i.inSelectedNamespace(obj) {
+		return
+	}
 	key, err := cache.MetaNamespaceKeyFunc(obj)
 	if err != nil {
 		panic(err)
 	}
-	i.collector.EventType.WithLabelValues(metrics.EventTypeAdd).Inc()
+	i.collector.EventType.WithLabelValues(i.resourcePluralName, metrics.EventTypeAdd).Inc()
+	if i.ownerKind != "" {
+		i.enqueueOwners(obj)
+		return
+	}
+	i.recordEvent(key, queuedEvent{eventType: EventTypeAdd})
 	i.queue.Add(key)
 }
 
 func (i *informer) handleDeleteResourceEvent(obj interface{}) {
+	if !i.inSelectedNamespace(obj) {
+		return
+	}
 	// For deletes we have to use this key function
 	// to handle the DeletedFinalStateUnknown case for the object
 	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
 	if err != nil {
 		panic(err)
 	}
+	if i.ownerKind != "" {
+		i.collector.EventType.WithLabelValues(i.resourcePluralName, metrics.EventTypeDelete).Inc()
+		i.enqueueOwners(obj)
+		return
+	}
 
-	// TODO: Revisit the need for passing delete events to the handler
+	// The informer may have missed the deletion, in which case the last known
+	// state of the object is wrapped in a DeletedFinalStateUnknown.
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		logrus.Errorf("failed to save last known state of deleted object (%s): unexpected type %T", key, obj)
+		return
+	}
 	// Save the last known state for the deleted object
-	i.deletedObjects[key] = obj.(*unstructured.Unstructured).DeepCopy()
-	i.collector.EventType.WithLabelValues(metrics.EventTypeDelete).Inc()
+	i.tombstones.add(key, u.DeepCopy())
+	i.collector.EventType.WithLabelValues(i.resourcePluralName, metrics.EventTypeDelete).Inc()
 
+	i.recordEvent(key, queuedEvent{eventType: EventTypeDelete})
 	i.queue.Add(key)
 }
 
 func (i *informer) handleUpdateResourceEvent(oldObj, newObj interface{}) {
+	if !i.inSelectedNamespace(newObj) {
+		return
+	}
 	key, err := cache.MetaNamespaceKeyFunc(newObj)
 	if err != nil {
 		panic(err)
 	}
-	i.collector.EventType.WithLabelValues(metrics.EventTypeUpdate).Inc()
+	i.collector.EventType.WithLabelValues(i.resourcePluralName, metrics.EventTypeUpdate).Inc()
+	if !i.passesPredicates(oldObj, newObj) {
+		i.collector.FilteredEvents.WithLabelValues(i.resourcePluralName, metrics.EventTypeUpdate).Inc()
+		return
+	}
+	if i.ownerKind != "" {
+		// The owners may have changed, so both the old and the new ones are enqueued.
+		i.enqueueOwners(oldObj)
+		i.enqueueOwners(newObj)
+		return
+	}
+	event := queuedEvent{eventType: EventTypeUpdate, oldObj: oldObj.(*unstructured.Unstructured)}
+	// The informer sends an update with the unchanged object on every resync.
+	if event.oldObj.GetResourceVersion() == newObj.(*unstructured.Unstructured).GetResourceVersion() {
+		event = queuedEvent{eventType: EventTypeResync}
+	}
+	i.recordEvent(key, event)
 	i.queue.Add(key)
 }
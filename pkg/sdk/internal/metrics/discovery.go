@@ -0,0 +1,57 @@
+// Copyright 2018 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"time"
+
+	"github.com/operator-framework/operator-sdk/pkg/k8sclient"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+)
+
+const (
+	discoveryRefreshesMetricName        = "operator_discovery_refreshes_total"
+	discoveryRefreshDurationMetricName  = "operator_discovery_refresh_duration_seconds"
+	discoverySkippedRefreshesMetricName = "operator_discovery_skipped_refreshes_total"
+	// DiscoveryResultNoMatch - refresh after which the kind was still not found label
+	DiscoveryResultNoMatch = "no_match"
+)
+
+// DiscoveryMetrics returns the metrics of the discovery information refreshes,
+// to be set with k8sclient.SetDiscoveryMetrics.
+func (c *Collector) DiscoveryMetrics() k8sclient.DiscoveryMetrics {
+	return discoveryMetrics{c: c}
+}
+
+type discoveryMetrics struct {
+	c *Collector
+}
+
+func (m discoveryMetrics) ObserveRefresh(duration time.Duration, err error) {
+	result := ReconcileResultSuccess
+	switch {
+	case meta.IsNoMatchError(err):
+		result = DiscoveryResultNoMatch
+	case err != nil:
+		result = ReconcileResultFailure
+	}
+	m.c.DiscoveryRefreshes.WithLabelValues(result).Inc()
+	m.c.DiscoveryRefreshDuration.Observe(duration.Seconds())
+}
+
+func (m discoveryMetrics) ObserveSkippedRefresh() {
+	m.c.DiscoverySkippedRefreshes.Inc()
+}
@@ -22,8 +22,19 @@ import (
 )
 
 const (
-	eventTypesMetricName       = "operator_event_types_total"
-	reconcileResultsMetricName = "operator_reconcile_results_total"
+	eventTypesMetricName        = "operator_event_types_total"
+	reconcileResultsMetricName  = "operator_reconcile_results_total"
+	filteredEventsMetricName    = "operator_filtered_events_total"
+	tombstonesMetricName        = "operator_tombstones"
+	concurrencyModeMetricName   = "operator_handler_concurrency_mode"
+	reconcileDurationMetricName = "operator_reconcile_duration_seconds"
+	lastSyncMetricName          = "operator_informer_last_sync_timestamp_seconds"
+	// ConcurrencyModeLabel - metric label for the concurrency mode of the handler
+	ConcurrencyModeLabel = "mode"
+	// ResourceLabel - metric label for the plural name of the watched resource
+	ResourceLabel = "resource"
+	// NamespaceLabel - metric label for the watched namespace, empty for all namespaces
+	NamespaceLabel = "namespace"
 	// EventTypeLabel - metric label for event type
 	EventTypeLabel = "type"
 	// EventTypeAdd - addition event label
@@ -46,8 +57,25 @@ var (
 
 // Collector - metric collector for all the metrics the sdk will watch
 type Collector struct {
-	EventType       *prom.CounterVec
-	ReconcileResult *prom.CounterVec
+	EventType         *prom.CounterVec
+	ReconcileResult   *prom.CounterVec
+	FilteredEvents    *prom.CounterVec
+	Tombstones        *prom.GaugeVec
+	ConcurrencyMode   *prom.GaugeVec
+	ReconcileDuration *prom.HistogramVec
+	LastSync          *prom.GaugeVec
+
+	// The metrics of the work queues, see WorkqueueMetricsProvider.
+	WorkqueueDepth        *prom.GaugeVec
+	WorkqueueAdds         *prom.CounterVec
+	WorkqueueLatency      *prom.SummaryVec
+	WorkqueueWorkDuration *prom.SummaryVec
+	WorkqueueRetries      *prom.CounterVec
+
+	// The metrics of the discovery information refreshes, see DiscoveryMetrics.
+	DiscoveryRefreshes        *prom.CounterVec
+	DiscoveryRefreshDuration  prom.Histogram
+	DiscoverySkippedRefreshes prom.Counter
 }
 
 // New - create a new Collector
@@ -55,12 +83,66 @@ func New() *Collector {
 	return &Collector{
 		EventType: prom.NewCounterVec(prom.CounterOpts{
 			Name: eventTypesMetricName,
-			Help: "events that the sdk has recieved, segmented by type(add or delete or update)",
-		}, []string{EventTypeLabel}),
+			Help: "events that the sdk has recieved, segmented by resource and type(add or delete or update)",
+		}, []string{ResourceLabel, EventTypeLabel}),
 		ReconcileResult: prom.NewCounterVec(prom.CounterOpts{
 			Name: reconcileResultsMetricName,
-			Help: "reconcilation events that the sdk has processed segmented by result(success or failure)",
+			Help: "reconcilation events that the sdk has processed segmented by resource and result(success or failure)",
+		}, []string{ResourceLabel, ReconcileResultLabel}),
+		FilteredEvents: prom.NewCounterVec(prom.CounterOpts{
+			Name: filteredEventsMetricName,
+			Help: "events that were dropped by the predicates of a watch, segmented by resource and type(update)",
+		}, []string{ResourceLabel, EventTypeLabel}),
+		Tombstones: prom.NewGaugeVec(prom.GaugeOpts{
+			Name: tombstonesMetricName,
+			Help: "last known states of deleted objects that the sdk keeps until their delete event is handled, segmented by resource",
+		}, []string{ResourceLabel}),
+		ConcurrencyMode: prom.NewGaugeVec(prom.GaugeOpts{
+			Name: concurrencyModeMetricName,
+			Help: "the concurrency mode of the calls into the handler(parallel or serial or per-key), set to 1 for the mode in use",
+		}, []string{ConcurrencyModeLabel}),
+		ReconcileDuration: prom.NewHistogramVec(prom.HistogramOpts{
+			Name:    reconcileDurationMetricName,
+			Help:    "time the handler took to process an event, segmented by resource",
+			Buckets: prom.DefBuckets,
+		}, []string{ResourceLabel}),
+		LastSync: prom.NewGaugeVec(prom.GaugeOpts{
+			Name: lastSyncMetricName,
+			Help: "unix time of the last full list of a watch from the API server, segmented by resource and namespace",
+		}, []string{ResourceLabel, NamespaceLabel}),
+		WorkqueueDepth: prom.NewGaugeVec(prom.GaugeOpts{
+			Name: workqueueDepthMetricName,
+			Help: "keys waiting in the work queue of a watch, segmented by resource",
+		}, []string{ResourceLabel}),
+		WorkqueueAdds: prom.NewCounterVec(prom.CounterOpts{
+			Name: workqueueAddsMetricName,
+			Help: "keys added to the work queue of a watch, segmented by resource",
+		}, []string{ResourceLabel}),
+		WorkqueueLatency: prom.NewSummaryVec(prom.SummaryOpts{
+			Name: workqueueLatencyMetricName,
+			Help: "time a key waits in the work queue of a watch before it is processed, segmented by resource",
+		}, []string{ResourceLabel}),
+		WorkqueueWorkDuration: prom.NewSummaryVec(prom.SummaryOpts{
+			Name: workqueueWorkDurationMetricName,
+			Help: "time a key from the work queue of a watch takes to process, segmented by resource",
+		}, []string{ResourceLabel}),
+		WorkqueueRetries: prom.NewCounterVec(prom.CounterOpts{
+			Name: workqueueRetriesMetricName,
+			Help: "rate limited retries of keys in the work queue of a watch, segmented by resource",
+		}, []string{ResourceLabel}),
+		DiscoveryRefreshes: prom.NewCounterVec(prom.CounterOpts{
+			Name: discoveryRefreshesMetricName,
+			Help: "refreshes of the discovery information for a kind that was not found, segmented by result(success or no_match or failure)",
 		}, []string{ReconcileResultLabel}),
+		DiscoveryRefreshDuration: prom.NewHistogram(prom.HistogramOpts{
+			Name:    discoveryRefreshDurationMetricName,
+			Help:    "time a refresh of the discovery information took",
+			Buckets: prom.DefBuckets,
+		}),
+		DiscoverySkippedRefreshes: prom.NewCounter(prom.CounterOpts{
+			Name: discoverySkippedRefreshesMetricName,
+			Help: "refreshes of the discovery information for a kind that was not found that were skipped because the last refresh was too recent",
+		}),
 	}
 }
 
@@ -78,11 +160,36 @@ func RegisterCollector(c *Collector) {
 func (c *Collector) Describe(ch chan<- *prom.Desc) {
 	c.EventType.Describe(ch)
 	c.ReconcileResult.Describe(ch)
-
+	c.FilteredEvents.Describe(ch)
+	c.Tombstones.Describe(ch)
+	c.ConcurrencyMode.Describe(ch)
+	c.ReconcileDuration.Describe(ch)
+	c.LastSync.Describe(ch)
+	c.WorkqueueDepth.Describe(ch)
+	c.WorkqueueAdds.Describe(ch)
+	c.WorkqueueLatency.Describe(ch)
+	c.WorkqueueWorkDuration.Describe(ch)
+	c.WorkqueueRetries.Describe(ch)
+	c.DiscoveryRefreshes.Describe(ch)
+	c.DiscoveryRefreshDuration.Describe(ch)
+	c.DiscoverySkippedRefreshes.Describe(ch)
 }
 
 // Collect returns the current state of the metrics
 func (c *Collector) Collect(ch chan<- prom.Metric) {
 	c.EventType.Collect(ch)
 	c.ReconcileResult.Collect(ch)
+	c.FilteredEvents.Collect(ch)
+	c.Tombstones.Collect(ch)
+	c.ConcurrencyMode.Collect(ch)
+	c.ReconcileDuration.Collect(ch)
+	c.LastSync.Collect(ch)
+	c.WorkqueueDepth.Collect(ch)
+	c.WorkqueueAdds.Collect(ch)
+	c.WorkqueueLatency.Collect(ch)
+	c.WorkqueueWorkDuration.Collect(ch)
+	c.WorkqueueRetries.Collect(ch)
+	c.DiscoveryRefreshes.Collect(ch)
+	c.DiscoveryRefreshDuration.Collect(ch)
+	c.DiscoverySkippedRefreshes.Collect(ch)
 }
@@ -0,0 +1,58 @@
+// Copyright 2018 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"k8s.io/client-go/util/workqueue"
+)
+
+const (
+	workqueueDepthMetricName        = "operator_workqueue_depth"
+	workqueueAddsMetricName         = "operator_workqueue_adds_total"
+	workqueueLatencyMetricName      = "operator_workqueue_queue_latency_microseconds"
+	workqueueWorkDurationMetricName = "operator_workqueue_work_duration_microseconds"
+	workqueueRetriesMetricName      = "operator_workqueue_retries_total"
+)
+
+// WorkqueueMetricsProvider returns a provider for the metrics of the work queues,
+// to be set with workqueue.SetProvider before any queue is created.
+// The queues of the watches are named after the plural name of their resource.
+func (c *Collector) WorkqueueMetricsProvider() workqueue.MetricsProvider {
+	return workqueueMetricsProvider{c: c}
+}
+
+type workqueueMetricsProvider struct {
+	c *Collector
+}
+
+func (p workqueueMetricsProvider) NewDepthMetric(name string) workqueue.GaugeMetric {
+	return p.c.WorkqueueDepth.WithLabelValues(name)
+}
+
+func (p workqueueMetricsProvider) NewAddsMetric(name string) workqueue.CounterMetric {
+	return p.c.WorkqueueAdds.WithLabelValues(name)
+}
+
+func (p workqueueMetricsProvider) NewLatencyMetric(name string) workqueue.SummaryMetric {
+	return p.c.WorkqueueLatency.WithLabelValues(name)
+}
+
+func (p workqueueMetricsProvider) NewWorkDurationMetric(name string) workqueue.SummaryMetric {
+	return p.c.WorkqueueWorkDuration.WithLabelValues(name)
+}
+
+func (p workqueueMetricsProvider) NewRetriesMetric(name string) workqueue.CounterMetric {
+	return p.c.WorkqueueRetries.WithLabelValues(name)
+}
@@ -0,0 +1,123 @@
+// Copyright 2018 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk
+
+import (
+	"strconv"
+
+	"github.com/operator-framework/operator-sdk/pkg/tlsutil"
+	"github.com/operator-framework/operator-sdk/pkg/util/k8sutil"
+)
+
+// metricsOp wraps all the options for ExposeMetricsPort().
+type metricsOp struct {
+	address         string
+	path            string
+	healthAddress   string
+	tlsConfig       *tlsutil.CertConfig
+	createService   bool
+	serviceLabels   map[string]string
+	serviceSelector map[string]string
+}
+
+// newMetricsOp creates a new default metricsOp
+func newMetricsOp() *metricsOp {
+	op := &metricsOp{createService: true}
+	op.setDefaults()
+	return op
+}
+
+func (op *metricsOp) applyOpts(opts []metricsOption) {
+	for _, opt := range opts {
+		opt(op)
+	}
+}
+
+func (op *metricsOp) setDefaults() {
+	if op.address == "" {
+		op.address = ":" + strconv.Itoa(k8sutil.PrometheusMetricsPort)
+	}
+	if op.path == "" {
+		op.path = k8sutil.PrometheusMetricsPath
+	}
+	if op.healthAddress == "" {
+		op.healthAddress = ":" + strconv.Itoa(k8sutil.HealthProbePort)
+	}
+}
+
+// metricsOption configures metricsOp.
+type metricsOption func(*metricsOp)
+
+// WithMetricsAddress sets the address the metrics server listens on, e.g "127.0.0.1:8383".
+// The port of the address is also the port of the metrics Service.
+// The default is ":60000".
+func WithMetricsAddress(address string) metricsOption {
+	return func(op *metricsOp) {
+		op.address = address
+	}
+}
+
+// WithMetricsPath sets the path the metrics are served at. The default is "/metrics".
+func WithMetricsPath(path string) metricsOption {
+	return func(op *metricsOp) {
+		op.path = path
+	}
+}
+
+// WithHealthAddress sets the address the /healthz and /readyz endpoints are served on
+// over plain HTTP, e.g "127.0.0.1:8081". The default is ":60001".
+func WithHealthAddress(address string) metricsOption {
+	return func(op *metricsOp) {
+		op.healthAddress = address
+	}
+}
+
+// WithMetricsTLS makes the metrics server serve HTTPS with a serving certificate for the
+// metrics Service, generated by pkg/tlsutil with the given config.
+// The certificate and its CA are stored in Secrets and a ConfigMap in the operator's namespace,
+// named after the Service, e.g "service-<OPERATOR_NAME>-<config.CertName>" for the certificate.
+// Prometheus can verify the certificate with the CA from the ConfigMap "service-<OPERATOR_NAME>-ca".
+// The metrics are not served if the certificate cannot be generated.
+func WithMetricsTLS(config *tlsutil.CertConfig) metricsOption {
+	return func(op *metricsOp) {
+		op.tlsConfig = config
+	}
+}
+
+// WithoutMetricsService makes ExposeMetricsPort() serve the metrics without
+// creating a Service for them, e.g if the Service is deployed with the operator.
+// OPERATOR_NAME is then only needed with WithMetricsTLS, to name the certificate.
+func WithoutMetricsService() metricsOption {
+	return func(op *metricsOp) {
+		op.createService = false
+	}
+}
+
+// WithMetricsServiceLabels adds the labels to the metrics Service,
+// e.g to match the selector of a ServiceMonitor.
+func WithMetricsServiceLabels(labels map[string]string) metricsOption {
+	return func(op *metricsOp) {
+		op.serviceLabels = labels
+	}
+}
+
+// WithMetricsServiceSelector sets the selector of the metrics Service for the operator pods.
+// The default selects the pods with the label "name=<OPERATOR_NAME>", like the generated
+// deploy/operator.yaml.
+func WithMetricsServiceSelector(selector map[string]string) metricsOption {
+	return func(op *metricsOp) {
+		op.serviceSelector = selector
+	}
+}
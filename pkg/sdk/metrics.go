@@ -15,29 +15,137 @@
 package sdk
 
 import (
+	"crypto/tls"
+	"fmt"
+	"net"
 	"net/http"
 	"strconv"
 
+	"github.com/operator-framework/operator-sdk/pkg/k8sclient"
+	"github.com/operator-framework/operator-sdk/pkg/tlsutil"
 	k8sutil "github.com/operator-framework/operator-sdk/pkg/util/k8sutil"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/sirupsen/logrus"
+	"k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
-// ExposeMetricsPort generate a Kubernetes Service to expose metrics port
-func ExposeMetricsPort() {
-	http.Handle("/"+k8sutil.PrometheusMetricsPortName, promhttp.Handler())
-	go http.ListenAndServe(":"+strconv.Itoa(k8sutil.PrometheusMetricsPort), nil)
+// ExposeMetricsPort serves the operator metrics and generates a Kubernetes Service to expose them.
+// It also serves the /healthz and /readyz endpoints for the liveness and readiness probes of
+// the operator on a separate plain HTTP port, see AddHealthCheck and AddReadyCheck.
+// "opts" configures the address, path and TLS of the metrics server, and the Service.
+// Errors are logged, as the operator keeps working without its metrics.
+func ExposeMetricsPort(opts ...metricsOption) {
+	o := newMetricsOp()
+	o.applyOpts(opts)
 
-	service, err := k8sutil.InitOperatorService()
-	if err != nil {
-		logrus.Errorf("failed to initialize service object for operator metrics: %v", err)
+	// The probes do not depend on the metrics TLS setup or the Service.
+	health := http.NewServeMux()
+	health.Handle(healthzPath, healthzHandler())
+	health.Handle(readyzPath, readyzHandler())
+	go serve("health probes", &http.Server{Addr: o.healthAddress, Handler: health}, false)
+
+	http.Handle(o.path, promhttp.Handler())
+	server := &http.Server{Addr: o.address}
+	var service *v1.Service
+	// The serving certificate is issued for the service, even if it is not created.
+	if o.createService || o.tlsConfig != nil {
+		var err error
+		if service, err = newMetricsService(o); err != nil {
+			logrus.Errorf("failed to initialize service object for operator metrics: %v", err)
+		}
+	}
+	if o.tlsConfig != nil {
+		cert, err := newMetricsCertificate(service, o.tlsConfig)
+		if err != nil {
+			// The metrics are not served over plain HTTP instead.
+			logrus.Errorf("failed to generate certificate for operator metrics: %v", err)
+		} else {
+			server.TLSConfig = &tls.Config{Certificates: []tls.Certificate{cert}}
+			go serve("metrics", server, true)
+		}
+	} else {
+		go serve("metrics", server, false)
+	}
+
+	if service == nil || !o.createService {
 		return
 	}
-	err = Create(service)
+	err := Create(service)
 	if err != nil && !errors.IsAlreadyExists(err) {
 		logrus.Errorf("failed to create service for operator metrics: %v", err)
 		return
 	}
 	logrus.Infof("Metrics service %s created", service.Name)
 }
+
+func serve(what string, server *http.Server, useTLS bool) {
+	var err error
+	if useTLS {
+		err = server.ListenAndServeTLS("", "")
+	} else {
+		err = server.ListenAndServe()
+	}
+	if err != nil && err != http.ErrServerClosed {
+		logrus.Errorf("failed to serve operator %s on (%s): %v", what, server.Addr, err)
+	}
+}
+
+// newMetricsService returns the Service for the metrics, with the port,
+// labels and selector set by the options.
+func newMetricsService(o *metricsOp) (*v1.Service, error) {
+	_, portStr, err := net.SplitHostPort(o.address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid metrics address (%s): %v", o.address, err)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid metrics port (%s): %v", portStr, err)
+	}
+	service, err := k8sutil.InitOperatorService()
+	if err != nil {
+		return nil, err
+	}
+	// The Deployment only names the default port, so the port is targeted by number.
+	service.Spec.Ports[0].Port = int32(port)
+	service.Spec.Ports[0].TargetPort = intstr.FromInt(port)
+	for k, v := range o.serviceLabels {
+		service.Labels[k] = v
+	}
+	if o.serviceSelector != nil {
+		service.Spec.Selector = o.serviceSelector
+	}
+	return service, nil
+}
+
+// newMetricsCertificate returns the serving certificate for the metrics service,
+// generated by pkg/tlsutil and stored in the service's namespace.
+func newMetricsCertificate(service *v1.Service, config *tlsutil.CertConfig) (tls.Certificate, error) {
+	if service == nil {
+		return tls.Certificate{}, fmt.Errorf("no metrics service to issue the certificate for")
+	}
+	f, err := k8sclient.DefaultFactory()
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	cg := tlsutil.NewSDKCertGenerator(f.KubeClient())
+	secret, _, _, err := cg.GenerateCert(service, service, config)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.X509KeyPair(secret.Data[v1.TLSCertKey], secret.Data[v1.TLSPrivateKeyKey])
+}
+
+// RegisterMetrics registers the collectors of the operator's own metrics, which are
+// then served next to the sdk's metrics by ExposeMetricsPort.
+// Returns an error if a collector or one of its metrics is already registered.
+func RegisterMetrics(collectors ...prometheus.Collector) error {
+	for _, c := range collectors {
+		if err := prometheus.Register(c); err != nil {
+			return fmt.Errorf("failed to register metrics collector: %v", err)
+		}
+	}
+	return nil
+}
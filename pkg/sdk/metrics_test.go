@@ -0,0 +1,217 @@
+// Copyright 2018 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/operator-framework/operator-sdk/pkg/sdk/internal/metrics"
+	"github.com/operator-framework/operator-sdk/pkg/tlsutil"
+	"github.com/operator-framework/operator-sdk/pkg/util/k8sutil"
+
+	"github.com/prometheus/client_golang/prometheus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/dynamic"
+)
+
+func TestNewMetricsService(t *testing.T) {
+	os.Setenv(k8sutil.OperatorNameEnvVar, "app-operator")
+	defer os.Unsetenv(k8sutil.OperatorNameEnvVar)
+	os.Setenv(k8sutil.WatchNamespaceEnvVar, "ns1")
+	defer os.Unsetenv(k8sutil.WatchNamespaceEnvVar)
+
+	type Scenario struct {
+		name               string
+		opts               []metricsOption
+		expectedPort       int32
+		expectedTargetPort intstr.IntOrString
+		expectedLabels     map[string]string
+		expectedSelector   map[string]string
+		expectError        bool
+	}
+
+	tests := []Scenario{
+		Scenario{
+			name:               "Defaults",
+			expectedPort:       k8sutil.PrometheusMetricsPort,
+			expectedTargetPort: intstr.FromInt(k8sutil.PrometheusMetricsPort),
+			expectedLabels:     map[string]string{"name": "app-operator"},
+			expectedSelector:   map[string]string{"name": "app-operator"},
+		},
+		Scenario{
+			name: "Custom address, labels and selector",
+			opts: []metricsOption{
+				WithMetricsAddress("127.0.0.1:8383"),
+				WithMetricsServiceLabels(map[string]string{"monitoring": "true"}),
+				WithMetricsServiceSelector(map[string]string{"app": "operator"}),
+			},
+			expectedPort:       8383,
+			expectedTargetPort: intstr.FromInt(8383),
+			expectedLabels:     map[string]string{"name": "app-operator", "monitoring": "true"},
+			expectedSelector:   map[string]string{"app": "operator"},
+		},
+		Scenario{
+			name:        "Address without port",
+			opts:        []metricsOption{WithMetricsAddress("127.0.0.1")},
+			expectError: true,
+		},
+	}
+
+	for _, test := range tests {
+		o := newMetricsOp()
+		o.applyOpts(test.opts)
+		service, err := newMetricsService(o)
+		if test.expectError {
+			if err == nil {
+				t.Errorf("test %s failed, expected an error", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("test %s failed: %v", test.name, err)
+			continue
+		}
+		if port := service.Spec.Ports[0].Port; port != test.expectedPort {
+			t.Errorf("test %s failed, expected port: %d; got: %d", test.name, test.expectedPort, port)
+		}
+		if targetPort := service.Spec.Ports[0].TargetPort; targetPort != test.expectedTargetPort {
+			t.Errorf("test %s failed, expected target port: %s; got: %s", test.name, test.expectedTargetPort.String(), targetPort.String())
+		}
+		if !reflect.DeepEqual(service.Labels, test.expectedLabels) {
+			t.Errorf("test %s failed, expected labels: %v; got: %v", test.name, test.expectedLabels, service.Labels)
+		}
+		if !reflect.DeepEqual(service.Spec.Selector, test.expectedSelector) {
+			t.Errorf("test %s failed, expected selector: %v; got: %v", test.name, test.expectedSelector, service.Spec.Selector)
+		}
+	}
+}
+
+func freeAddress(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer l.Close()
+	return l.Addr().String()
+}
+
+func TestExposeMetricsPortServesHealthWithoutCertificate(t *testing.T) {
+	// Without OPERATOR_NAME neither the Service nor the certificate can be generated.
+	os.Unsetenv(k8sutil.OperatorNameEnvVar)
+	healthAddress := freeAddress(t)
+	ExposeMetricsPort(
+		WithMetricsAddress(freeAddress(t)),
+		WithMetricsPath("/tls-metrics"),
+		WithHealthAddress(healthAddress),
+		WithMetricsTLS(&tlsutil.CertConfig{CertName: "metrics"}),
+	)
+
+	var resp *http.Response
+	var err error
+	for i := 0; i < 50; i++ {
+		if resp, err = http.Get("http://" + healthAddress + healthzPath); err == nil {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("expected the health probes to be served; got: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status %d; got: %d", http.StatusOK, resp.StatusCode)
+	}
+}
+
+// emptyResourceClient lists no objects.
+type emptyResourceClient struct {
+	dynamic.ResourceInterface
+}
+
+func (emptyResourceClient) List(opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	return &unstructured.UnstructuredList{}, nil
+}
+
+// gatherWidgetsMetric returns the value and the sample count of the metric with
+// the given name for the resource "widgets".
+func gatherWidgetsMetric(t *testing.T, c *metrics.Collector, name string) (float64, uint64) {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(c)
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("failed to gather metrics: %v", err)
+	}
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			for _, label := range m.GetLabel() {
+				if label.GetName() == "resource" && label.GetValue() == "widgets" {
+					return m.GetGauge().GetValue() + m.GetCounter().GetValue(), m.GetHistogram().GetSampleCount()
+				}
+			}
+		}
+	}
+	return 0, 0
+}
+
+func TestWatchMetrics(t *testing.T) {
+	RegisteredHandler = HandlerFunc(func(ctx context.Context, event Event) error {
+		return nil
+	})
+	defer func() { RegisteredHandler = nil }()
+
+	c := getCollector()
+	i := newInformer("v1", "Pod", "widgets", "ns1", emptyResourceClient{}, 0, c, newWatchOp())
+	i.context = context.TODO()
+	defer i.queue.ShutDown()
+	if err := i.sharedIndexInformer.GetIndexer().Add(newTestPod("ns1", "a", "web", "uid-1")); err != nil {
+		t.Fatalf("failed to add pod: %v", err)
+	}
+
+	// The queue of the watch reports its metrics under the plural name of its resource.
+	i.queue.Add("ns1/a")
+	if depth, _ := gatherWidgetsMetric(t, c, "operator_workqueue_depth"); depth != 1 {
+		t.Errorf("expected queue depth: 1; got: %v", depth)
+	}
+	if adds, _ := gatherWidgetsMetric(t, c, "operator_workqueue_adds_total"); adds != 1 {
+		t.Errorf("expected queue adds: 1; got: %v", adds)
+	}
+
+	i.processNextItem()
+	if depth, _ := gatherWidgetsMetric(t, c, "operator_workqueue_depth"); depth != 0 {
+		t.Errorf("expected queue depth: 0; got: %v", depth)
+	}
+	if _, count := gatherWidgetsMetric(t, c, "operator_reconcile_duration_seconds"); count != 1 {
+		t.Errorf("expected 1 observed reconcile duration; got: %d", count)
+	}
+
+	lw := newListWatcherFromResourceClient(emptyResourceClient{}, "", "", i.recordListed)
+	if _, err := lw.List(metav1.ListOptions{}); err != nil {
+		t.Fatalf("failed to list: %v", err)
+	}
+	if lastSync, _ := gatherWidgetsMetric(t, c, "operator_informer_last_sync_timestamp_seconds"); lastSync < float64(time.Now().Add(-time.Minute).Unix()) {
+		t.Errorf("expected the time of the last list; got: %v", lastSync)
+	}
+}
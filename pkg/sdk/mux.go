@@ -0,0 +1,119 @@
+// Copyright 2018 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// HandlerFunc is an adapter to allow the use of ordinary functions as a Handler.
+type HandlerFunc func(context.Context, Event) error
+
+// Handle calls f(ctx, event).
+func (f HandlerFunc) Handle(ctx context.Context, event Event) error {
+	return f(ctx, event)
+}
+
+// Middleware wraps a handler with additional behavior, e.g logging or tracing.
+type Middleware func(ResultHandler) ResultHandler
+
+// NoRouteError is returned by a Mux when an event has no handler registered for
+// its apiVersion and kind and no fallback handler is set.
+type NoRouteError struct {
+	APIVersion string
+	Kind       string
+}
+
+func (e *NoRouteError) Error() string {
+	return fmt.Sprintf("no handler registered for (apiVersion:%s, kind:%s)", e.APIVersion, e.Kind)
+}
+
+// IsNoRouteError returns true if the error indicates that an event had no route.
+func IsNoRouteError(err error) bool {
+	_, ok := err.(*NoRouteError)
+	return ok
+}
+
+// Mux is a Handler that dispatches events to the handler registered
+// for the apiVersion and kind of the event object.
+// Events that match no registered handler are sent to the fallback handler, if set.
+type Mux struct {
+	mu       sync.RWMutex
+	routes   map[schema.GroupVersionKind]ResultHandler
+	fallback ResultHandler
+}
+
+// NewMux creates an empty Mux.
+func NewMux() *Mux {
+	return &Mux{routes: map[schema.GroupVersionKind]ResultHandler{}}
+}
+
+// HandleFor registers the handler for events on objects of the given apiVersion and kind.
+// The middlewares are applied in order, so the first middleware is the outermost one.
+// Registering a handler for an already registered apiVersion and kind replaces it.
+func (m *Mux) HandleFor(apiVersion, kind string, handler Handler, middlewares ...Middleware) {
+	m.HandleResultFor(apiVersion, kind, toResultHandler(handler), middlewares...)
+}
+
+// HandleResultFor is like HandleFor, but registers a ResultHandler.
+func (m *Mux) HandleResultFor(apiVersion, kind string, handler ResultHandler, middlewares ...Middleware) {
+	gvk := schema.FromAPIVersionAndKind(apiVersion, kind)
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.routes[gvk] = handler
+}
+
+// HandleFallback registers the handler for events that match no other route.
+func (m *Mux) HandleFallback(handler Handler) {
+	m.HandleResultFallback(toResultHandler(handler))
+}
+
+// HandleResultFallback is like HandleFallback, but registers a ResultHandler.
+func (m *Mux) HandleResultFallback(handler ResultHandler) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.fallback = handler
+}
+
+// Handle dispatches the event like HandleResult, and drops the Result.
+func (m *Mux) Handle(ctx context.Context, event Event) error {
+	_, err := m.HandleResult(ctx, event)
+	return err
+}
+
+// HandleResult dispatches the event to the handler registered for the event object's
+// apiVersion and kind, or to the fallback handler.
+// Returns a *NoRouteError if neither exists.
+func (m *Mux) HandleResult(ctx context.Context, event Event) (Result, error) {
+	gvk := event.Object.GetObjectKind().GroupVersionKind()
+	m.mu.RLock()
+	handler, ok := m.routes[gvk]
+	if !ok {
+		handler = m.fallback
+	}
+	m.mu.RUnlock()
+	if handler == nil {
+		apiVersion, kind := gvk.ToAPIVersionAndKind()
+		return Result{}, &NoRouteError{APIVersion: apiVersion, Kind: kind}
+	}
+	return handler.HandleResult(ctx, event)
+}
@@ -0,0 +1,120 @@
+// Copyright 2018 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func recordingHandler(name string, calls *[]string) Handler {
+	return HandlerFunc(func(ctx context.Context, event Event) error {
+		*calls = append(*calls, name)
+		return nil
+	})
+}
+
+func recordingMiddleware(name string, calls *[]string) Middleware {
+	return func(next ResultHandler) ResultHandler {
+		return ResultHandlerFunc(func(ctx context.Context, event Event) (Result, error) {
+			*calls = append(*calls, name)
+			return next.HandleResult(ctx, event)
+		})
+	}
+}
+
+func TestMuxHandle(t *testing.T) {
+	pod := &corev1.Pod{TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"}}
+	deployment := &appsv1.Deployment{TypeMeta: metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"}}
+
+	type Scenario struct {
+		name          string
+		object        Object
+		fallback      bool
+		expectedCalls []string
+		expectNoRoute bool
+	}
+
+	tests := []Scenario{
+		Scenario{
+			name:          "Routed kind with middlewares",
+			object:        pod,
+			expectedCalls: []string{"outer", "inner", "pods"},
+		},
+		Scenario{
+			name:          "Unrouted kind with fallback",
+			object:        deployment,
+			fallback:      true,
+			expectedCalls: []string{"fallback"},
+		},
+		Scenario{
+			name:          "Unrouted kind without fallback",
+			object:        deployment,
+			expectNoRoute: true,
+		},
+	}
+
+	for _, test := range tests {
+		var calls []string
+		m := NewMux()
+		m.HandleFor("v1", "Pod", recordingHandler("pods", &calls), recordingMiddleware("outer", &calls), recordingMiddleware("inner", &calls))
+		if test.fallback {
+			m.HandleFallback(recordingHandler("fallback", &calls))
+		}
+
+		err := m.Handle(context.TODO(), Event{Object: test.object})
+		if IsNoRouteError(err) != test.expectNoRoute {
+			t.Errorf("test %s failed, expected no route error: %v; got: %v", test.name, test.expectNoRoute, err)
+		}
+		if len(calls) != len(test.expectedCalls) {
+			t.Errorf("test %s failed, expected calls: %v; got: %v", test.name, test.expectedCalls, calls)
+			continue
+		}
+		for i := range calls {
+			if calls[i] != test.expectedCalls[i] {
+				t.Errorf("test %s failed, expected calls: %v; got: %v", test.name, test.expectedCalls, calls)
+				break
+			}
+		}
+	}
+}
+
+func TestMuxHandleResult(t *testing.T) {
+	pod := &corev1.Pod{TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"}}
+	expected := Result{RequeueAfter: time.Minute}
+
+	m := NewMux()
+	m.HandleResultFor("v1", "Pod", ResultHandlerFunc(func(ctx context.Context, event Event) (Result, error) {
+		return expected, nil
+	}))
+	result, err := m.HandleResult(context.TODO(), Event{Object: pod})
+	if err != nil || result != expected {
+		t.Errorf("expected result: %v; got: %v, %v", expected, result, err)
+	}
+
+	// A Handler registered on the Mux always returns the zero Result.
+	m.HandleFor("v1", "Pod", HandlerFunc(func(ctx context.Context, event Event) error {
+		return nil
+	}))
+	result, err = m.HandleResult(context.TODO(), Event{Object: pod})
+	if err != nil || result != (Result{}) {
+		t.Errorf("expected result: %v; got: %v, %v", Result{}, result, err)
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright 2018 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+)
+
+// namespaceInformer caches the namespaces for the namespace selector of a watch. It is shared
+// by the informers of the watch, so that a watch of several namespaces lists and watches
+// the namespaces only once.
+type namespaceInformer struct {
+	cache.SharedIndexInformer
+
+	mu sync.Mutex
+	// users is the number of running informers that filter events with the namespace informer,
+	// and stop stops it once there are none left.
+	users int
+	stop  context.CancelFunc
+}
+
+// newNamespaceInformer returns a namespaceInformer whose namespaceClient lists and watches namespaces.
+func newNamespaceInformer(namespaceClient dynamic.ResourceInterface, resyncPeriod time.Duration) *namespaceInformer {
+	return &namespaceInformer{
+		SharedIndexInformer: cache.NewSharedIndexInformer(
+			newListWatcherFromResourceClient(namespaceClient, "", "", nil), &unstructured.Unstructured{}, resyncPeriod, cache.Indexers{},
+		),
+	}
+}
+
+// start runs the namespace informer for its first user, and waits until its cache is synced.
+// Returns false if ctx is done before. Each call to start must be followed by a call to release.
+func (n *namespaceInformer) start(ctx context.Context) bool {
+	n.mu.Lock()
+	if n.users == 0 {
+		var runCtx context.Context
+		runCtx, n.stop = context.WithCancel(context.Background())
+		go n.Run(runCtx.Done())
+	}
+	n.users++
+	n.mu.Unlock()
+	return cache.WaitForCacheSync(ctx.Done(), n.HasSynced)
+}
+
+// release stops the namespace informer once all its users released it.
+func (n *namespaceInformer) release() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.users--
+	if n.users == 0 {
+		n.stop()
+	}
+}
+
+// filterNamespaces makes the informer drop the events for objects in namespaces
+// whose labels do not match the selector, as cached by the namespace informer.
+func (i *informer) filterNamespaces(selector labels.Selector, namespaces *namespaceInformer) {
+	i.namespaceSelector = selector
+	i.namespaceInformer = namespaces
+	i.namespaceInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    i.handleAddNamespaceEvent,
+		UpdateFunc: i.handleUpdateNamespaceEvent,
+	})
+}
+
+// inSelectedNamespace returns true if the namespace of obj matches the namespace selector.
+func (i *informer) inSelectedNamespace(obj interface{}) bool {
+	if i.namespaceSelector == nil {
+		return true
+	}
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		logrus.Errorf("failed to get namespace of %s object: %v", i.resourcePluralName, err)
+		return false
+	}
+	if accessor.GetNamespace() == "" {
+		return true
+	}
+	ns, exists, err := i.namespaceInformer.GetIndexer().GetByKey(accessor.GetNamespace())
+	if err != nil || !exists {
+		return false
+	}
+	return i.namespaceMatches(ns)
+}
+
+func (i *informer) namespaceMatches(ns interface{}) bool {
+	accessor, err := meta.Accessor(ns)
+	if err != nil {
+		return false
+	}
+	return i.namespaceSelector.Matches(labels.Set(accessor.GetLabels()))
+}
+
+func (i *informer) handleAddNamespaceEvent(obj interface{}) {
+	if i.namespaceMatches(obj) {
+		i.enqueueNamespace(obj)
+	}
+}
+
+func (i *informer) handleUpdateNamespaceEvent(oldObj, newObj interface{}) {
+	if !i.namespaceMatches(oldObj) && i.namespaceMatches(newObj) {
+		i.enqueueNamespace(newObj)
+	}
+}
+
+// enqueueNamespace adds the keys of all cached objects in the namespace to the queue.
+func (i *informer) enqueueNamespace(ns interface{}) {
+	accessor, err := meta.Accessor(ns)
+	if err != nil {
+		return
+	}
+	objs, err := i.sharedIndexInformer.GetIndexer().ByIndex(cache.NamespaceIndex, accessor.GetName())
+	if err != nil {
+		logrus.Errorf("failed to list %s in namespace %s: %v", i.resourcePluralName, accessor.GetName(), err)
+		return
+	}
+	for _, obj := range objs {
+		if i.ownerKind != "" {
+			i.enqueueOwners(obj)
+			continue
+		}
+		key, err := cache.MetaNamespaceKeyFunc(obj)
+		if err != nil {
+			continue
+		}
+		i.queue.Add(key)
+	}
+}
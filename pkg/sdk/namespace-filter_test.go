@@ -0,0 +1,138 @@
+// Copyright 2018 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+)
+
+func newTestNamespace(name, team string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	u.SetAPIVersion("v1")
+	u.SetKind("Namespace")
+	u.SetName(name)
+	u.SetLabels(map[string]string{"team": team})
+	return u
+}
+
+func TestNamespaceFilter(t *testing.T) {
+	i := newTestInformer(t, "", newTestPod("ns1", "a", "web", "uid-1"), newTestPod("ns2", "b", "web", "uid-1"))
+	i.queue = workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	defer i.queue.ShutDown()
+	i.filterNamespaces(labels.SelectorFromSet(labels.Set{"team": "payments"}), newNamespaceInformer(nil, 0))
+	if err := i.namespaceInformer.GetIndexer().Add(newTestNamespace("ns1", "payments")); err != nil {
+		t.Fatalf("failed to add namespace to cache: %v", err)
+	}
+
+	type Scenario struct {
+		name     string
+		obj      interface{}
+		expected bool
+	}
+
+	tests := []Scenario{
+		Scenario{
+			name:     "Matching namespace",
+			obj:      newTestPod("ns1", "a", "web", "uid-1"),
+			expected: true,
+		},
+		Scenario{
+			name:     "Unknown namespace",
+			obj:      newTestPod("ns2", "b", "web", "uid-1"),
+			expected: false,
+		},
+		Scenario{
+			name:     "Tombstone in matching namespace",
+			obj:      cache.DeletedFinalStateUnknown{Key: "ns1/a", Obj: newTestPod("ns1", "a", "web", "uid-1")},
+			expected: true,
+		},
+	}
+
+	for _, test := range tests {
+		if got := i.inSelectedNamespace(test.obj); got != test.expected {
+			t.Errorf("test %s failed, expected: %v; got: %v", test.name, test.expected, got)
+		}
+	}
+
+	// The objects in a namespace are enqueued once it starts to match.
+	i.handleUpdateNamespaceEvent(newTestNamespace("ns2", "web"), newTestNamespace("ns2", "payments"))
+	if n := i.queue.Len(); n != 1 {
+		t.Fatalf("expected 1 queued object; got: %d", n)
+	}
+	if key, _ := i.queue.Get(); key != "ns2/b" {
+		t.Errorf("expected queued object: ns2/b; got: %v", key)
+	}
+}
+
+// namespaceClient lists no namespaces and counts the lists.
+type namespaceClient struct {
+	dynamic.ResourceInterface
+	mu      sync.Mutex
+	lists   int
+	watcher *watch.RaceFreeFakeWatcher
+}
+
+func (c *namespaceClient) List(opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lists++
+	return &unstructured.UnstructuredList{}, nil
+}
+
+func (c *namespaceClient) Watch(opts metav1.ListOptions) (watch.Interface, error) {
+	return c.watcher, nil
+}
+
+func TestNamespaceInformerIsShared(t *testing.T) {
+	client := &namespaceClient{watcher: watch.NewRaceFreeFake()}
+	namespaces := newNamespaceInformer(client, 0)
+
+	// The informers of a watch start the namespace informer once, and wait for its cache.
+	for n := 0; n < 3; n++ {
+		if !namespaces.start(context.TODO()) {
+			t.Fatalf("expected the namespace informer to sync")
+		}
+	}
+	client.mu.Lock()
+	lists := client.lists
+	client.mu.Unlock()
+	if lists != 1 {
+		t.Errorf("expected namespaces to be listed once; got: %d", lists)
+	}
+
+	// It is stopped once the last informer released it.
+	namespaces.release()
+	namespaces.release()
+	if client.watcher.IsStopped() {
+		t.Errorf("expected the namespace informer to run while it has users")
+	}
+	namespaces.release()
+	for start := time.Now(); !client.watcher.IsStopped(); time.Sleep(time.Millisecond) {
+		if time.Since(start) > 5*time.Second {
+			t.Fatalf("expected the namespace informer to be stopped")
+		}
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2018 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk
+
+import (
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/cache"
+)
+
+// enqueueOwners adds the keys of the owners of obj to the queue of the informer
+// that watches them. Owners that are not in that informer's cache are skipped,
+// since there is no state to deliver for them.
+func (i *informer) enqueueOwners(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		logrus.Errorf("failed to get owners of %s object: %v", i.resourcePluralName, err)
+		return
+	}
+	ownerGK := schema.FromAPIVersionAndKind(i.ownerAPIVersion, i.ownerKind).GroupKind()
+	for _, ref := range accessor.GetOwnerReferences() {
+		gv, err := schema.ParseGroupVersion(ref.APIVersion)
+		if err != nil || gv.WithKind(ref.Kind).GroupKind() != ownerGK {
+			continue
+		}
+		namespace := accessor.GetNamespace()
+		owner := ownerInformerFor(i.cluster, i.ownerAPIVersion, i.ownerKind, namespace)
+		if owner == nil {
+			logrus.Warnf("no watch for owner (apiVersion:%s, kind:%s, namespace:%s) of %s (%s)",
+				i.ownerAPIVersion, i.ownerKind, namespace, i.resourcePluralName, accessor.GetName())
+			return
+		}
+		// A namespaced owner is in the namespace of obj, a cluster-scoped one has no namespace.
+		for _, key := range []string{namespace + "/" + ref.Name, ref.Name} {
+			if _, exists, _ := owner.sharedIndexInformer.GetIndexer().GetByKey(key); exists {
+				owner.queue.Add(key)
+				break
+			}
+		}
+	}
+}
+
+// ownerInformerFor returns the informer that watches objects of the given
+// apiVersion and kind in the namespace of the cluster, or nil if there is none.
+func ownerInformerFor(cluster, apiVersion, kind, namespace string) *informer {
+	informersMu.RLock()
+	defer informersMu.RUnlock()
+	for _, i := range informers {
+		if i.cluster != cluster || i.apiVersion != apiVersion || i.kind != kind {
+			continue
+		}
+		if i.namespace != namespace && i.namespace != metav1.NamespaceAll {
+			continue
+		}
+		return i
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+// Copyright 2018 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+)
+
+func TestEnqueueOwners(t *testing.T) {
+	owner := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	owner.SetAPIVersion("v1")
+	owner.SetKind("ConfigMap")
+	owner.SetNamespace("ns1")
+	owner.SetName("owner")
+
+	owners := newTestInformer(t, "", owner)
+	owners.kind = "ConfigMap"
+	owners.queue = workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	defer owners.queue.ShutDown()
+	children := newTestInformer(t, "")
+	children.ownerAPIVersion = "v1"
+	children.ownerKind = "ConfigMap"
+
+	saved := informers
+	informers = []*informer{children, owners}
+	defer func() { informers = saved }()
+
+	// The owner of the pod in ns2 is not in the cache and is skipped.
+	children.enqueueOwners(newTestPod("ns1", "a", "web", "uid-1"))
+	children.enqueueOwners(cache.DeletedFinalStateUnknown{Key: "ns1/b", Obj: newTestPod("ns1", "b", "web", "uid-1")})
+	children.enqueueOwners(newTestPod("ns2", "c", "web", "uid-2"))
+
+	if n := owners.queue.Len(); n != 1 {
+		t.Fatalf("expected 1 queued owner; got: %d", n)
+	}
+	if key, _ := owners.queue.Get(); key != "ns1/owner" {
+		t.Errorf("expected queued owner: ns1/owner; got: %v", key)
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2018 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk
+
+import (
+	"reflect"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Predicate returns true if an update of a watched object from oldObj to newObj should be handled.
+// Predicates must not modify the objects.
+type Predicate func(oldObj, newObj *unstructured.Unstructured) bool
+
+// GenerationChanged is a Predicate that passes updates that change metadata.generation.
+// The API server increments the generation of most resources only on changes to the spec,
+// so updates of just the status or metadata are dropped. Note that custom resources
+// only have a generation with the status subresource enabled, and some resources,
+// e.g Pods, never set it.
+func GenerationChanged(oldObj, newObj *unstructured.Unstructured) bool {
+	return oldObj.GetGeneration() != newObj.GetGeneration()
+}
+
+// ResourceVersionChanged is a Predicate that passes updates that change metadata.resourceVersion.
+// It drops the periodic resync events for unchanged objects.
+func ResourceVersionChanged(oldObj, newObj *unstructured.Unstructured) bool {
+	return oldObj.GetResourceVersion() != newObj.GetResourceVersion()
+}
+
+// LabelsChanged is a Predicate that passes updates that change metadata.labels.
+func LabelsChanged(oldObj, newObj *unstructured.Unstructured) bool {
+	return !reflect.DeepEqual(oldObj.GetLabels(), newObj.GetLabels())
+}
+
+// AnnotationsChanged is a Predicate that passes updates that change metadata.annotations.
+func AnnotationsChanged(oldObj, newObj *unstructured.Unstructured) bool {
+	return !reflect.DeepEqual(oldObj.GetAnnotations(), newObj.GetAnnotations())
+}
+
+// AnyOf returns a Predicate that passes updates that pass any of the predicates,
+// e.g AnyOf(GenerationChanged, LabelsChanged) to handle changes to the spec or the labels.
+func AnyOf(predicates ...Predicate) Predicate {
+	return func(oldObj, newObj *unstructured.Unstructured) bool {
+		for _, p := range predicates {
+			if p(oldObj, newObj) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// passesPredicates returns true if the update passes all predicates of the informer.
+func (i *informer) passesPredicates(oldObj, newObj interface{}) bool {
+	if len(i.predicates) == 0 {
+		return true
+	}
+	oldU, ok := oldObj.(*unstructured.Unstructured)
+	if !ok {
+		return true
+	}
+	newU, ok := newObj.(*unstructured.Unstructured)
+	if !ok {
+		return true
+	}
+	for _, p := range i.predicates {
+		if !p(oldU, newU) {
+			return false
+		}
+	}
+	return true
+}
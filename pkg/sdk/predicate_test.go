@@ -0,0 +1,82 @@
+// Copyright 2018 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestPredicates(t *testing.T) {
+	newObj := func(generation int64, resourceVersion, app string) *unstructured.Unstructured {
+		u := newTestPod("ns1", "a", app, "uid-1")
+		u.SetGeneration(generation)
+		u.SetResourceVersion(resourceVersion)
+		return u
+	}
+
+	type Scenario struct {
+		name      string
+		predicate Predicate
+		oldObj    *unstructured.Unstructured
+		newObj    *unstructured.Unstructured
+		expected  bool
+	}
+
+	tests := []Scenario{
+		Scenario{
+			name:      "Generation changed",
+			predicate: GenerationChanged,
+			oldObj:    newObj(1, "10", "web"),
+			newObj:    newObj(2, "11", "web"),
+			expected:  true,
+		},
+		Scenario{
+			name:      "Status only update",
+			predicate: GenerationChanged,
+			oldObj:    newObj(1, "10", "web"),
+			newObj:    newObj(1, "11", "web"),
+			expected:  false,
+		},
+		Scenario{
+			name:      "Resync",
+			predicate: ResourceVersionChanged,
+			oldObj:    newObj(1, "10", "web"),
+			newObj:    newObj(1, "10", "web"),
+			expected:  false,
+		},
+		Scenario{
+			name:      "Labels changed",
+			predicate: LabelsChanged,
+			oldObj:    newObj(1, "10", "web"),
+			newObj:    newObj(1, "11", "db"),
+			expected:  true,
+		},
+		Scenario{
+			name:      "Any of generation or labels changed",
+			predicate: AnyOf(GenerationChanged, LabelsChanged),
+			oldObj:    newObj(1, "10", "web"),
+			newObj:    newObj(1, "11", "db"),
+			expected:  true,
+		},
+	}
+
+	for _, test := range tests {
+		if got := test.predicate(test.oldObj, test.newObj); got != test.expected {
+			t.Errorf("test %s failed, expected: %v; got: %v", test.name, test.expected, got)
+		}
+	}
+}
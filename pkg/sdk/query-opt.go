@@ -21,6 +21,7 @@ import (
 // GetOp wraps all the options for Get().
 type GetOp struct {
 	metaGetOptions *metav1.GetOptions
+	liveRead       bool
 }
 
 func NewGetOp() *GetOp {
@@ -51,9 +52,18 @@ func WithGetOptions(metaGetOptions *metav1.GetOptions) GetOption {
 	}
 }
 
+// WithLiveRead makes the Get() operation read the object from the API server,
+// even if its kind is watched and could be read from the cache.
+func WithLiveRead() GetOption {
+	return func(op *GetOp) {
+		op.liveRead = true
+	}
+}
+
 // ListOp wraps all the options for List.
 type ListOp struct {
 	metaListOptions *metav1.ListOptions
+	liveRead        bool
 }
 
 func NewListOp() *ListOp {
@@ -84,3 +94,11 @@ func WithListOptions(metaListOptions *metav1.ListOptions) ListOption {
 		op.metaListOptions = metaListOptions
 	}
 }
+
+// WithLiveListRead makes the List() operation read the objects from the API server,
+// even if their kind is watched and could be read from the cache.
+func WithLiveListRead() ListOption {
+	return func(op *ListOp) {
+		op.liveRead = true
+	}
+}
@@ -15,32 +15,50 @@
 package sdk
 
 import (
+	"context"
 	"fmt"
 
-	"github.com/operator-framework/operator-sdk/pkg/k8sclient"
 	"github.com/operator-framework/operator-sdk/pkg/util/k8sutil"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
 // Get gets the specified object and unmarshals the retrieved data into the "into" object.
 // "into" is a Object that must have
 // "Kind" and "APIVersion" specified in its "TypeMeta" field
 // and "Name" and "Namespace" specified in its "ObjectMeta" field.
+// If the object's kind is watched in its namespace without a label selector,
+// the object is read from the watch's cache instead of the API server.
 // "opts" configures the Get operation.
 //  When passed With WithGetOptions(o), the specified metav1.GetOptions is set.
+//  When passed With WithLiveRead(), the object is always read from the API server.
 func Get(into Object, opts ...GetOption) error {
+	return defaultClient.Get(into, opts...)
+}
+
+// GetWithContext is like Get, but a request to the API server is canceled once ctx is done.
+// It uses the Client from ClientFromContext(ctx).
+func GetWithContext(ctx context.Context, into Object, opts ...GetOption) error {
+	return ClientFromContext(ctx).Get(into, opts...)
+}
+
+// Get is like the package-level Get.
+func (c *dynamicClient) Get(into Object, opts ...GetOption) error {
 	name, namespace, err := k8sutil.GetNameAndNamespace(into)
 	if err != nil {
 		return err
 	}
 	gvk := into.GetObjectKind().GroupVersionKind()
 	apiVersion, kind := gvk.ToAPIVersionAndKind()
-	resourceClient, _, err := k8sclient.GetResourceClient(apiVersion, kind, namespace)
-	if err != nil {
-		return fmt.Errorf("failed to get resource client for (apiVersion:%s, kind:%s, ns:%s): %v", apiVersion, kind, namespace, err)
-	}
 	o := NewGetOp()
 	o.applyOpts(opts)
-	u, err := resourceClient.Get(name, *o.metaGetOptions)
+
+	var u *unstructured.Unstructured
+	if i := c.cacheFor(apiVersion, kind, namespace); i != nil && !o.liveRead {
+		u, err = i.cachedGet(namespace, name)
+	} else {
+		u, err = c.liveGet(apiVersion, kind, namespace, name, o)
+	}
 	if err != nil {
 		return err
 	}
@@ -54,18 +72,36 @@ func Get(into Object, opts ...GetOption) error {
 // "namespace" indicates which kubernetes namespace to look for the list of kubernetes objects.
 // "into" is a sdkType.Object that must have
 // "Kind" and "APIVersion" specified in its "TypeMeta" field
+// If the objects' kind is watched in the namespace without a label selector,
+// the objects are read from the watch's cache instead of the API server,
+// unless a field selector is set in the list options.
 // "opts" configures the List operation.
 //  When passed With WithListOptions(o), the specified metav1.ListOptions is set.
+//  When passed With WithLiveListRead(), the objects are always read from the API server.
 func List(namespace string, into Object, opts ...ListOption) error {
+	return defaultClient.List(namespace, into, opts...)
+}
+
+// ListWithContext is like List, but a request to the API server is canceled once ctx is done.
+// It uses the Client from ClientFromContext(ctx).
+func ListWithContext(ctx context.Context, namespace string, into Object, opts ...ListOption) error {
+	return ClientFromContext(ctx).List(namespace, into, opts...)
+}
+
+// List is like the package-level List.
+func (c *dynamicClient) List(namespace string, into Object, opts ...ListOption) error {
 	gvk := into.GetObjectKind().GroupVersionKind()
 	apiVersion, kind := gvk.ToAPIVersionAndKind()
-	resourceClient, _, err := k8sclient.GetResourceClient(apiVersion, kind, namespace)
-	if err != nil {
-		return fmt.Errorf("failed to get resource client for (apiVersion:%s, kind:%s, ns:%s): %v", apiVersion, kind, namespace, err)
-	}
 	o := NewListOp()
 	o.applyOpts(opts)
-	l, err := resourceClient.List(*o.metaListOptions)
+
+	var l *unstructured.UnstructuredList
+	var err error
+	if i := c.cacheFor(apiVersion, kind, namespace); i != nil && !o.liveRead && o.metaListOptions.FieldSelector == "" {
+		l, err = i.cachedList(namespace, *o.metaListOptions)
+	} else {
+		l, err = c.liveList(apiVersion, kind, namespace, o)
+	}
 	if err != nil {
 		return err
 	}
@@ -74,3 +110,49 @@ func List(namespace string, into Object, opts ...ListOption) error {
 	}
 	return nil
 }
+
+// ListByIndex retrieves the objects whose index named indexName contains indexedValue
+// from the cache of the watches with that index, and unmarshals them into the "into" object.
+// "namespace" indicates which kubernetes namespace to look for the objects, all namespaces if empty.
+// "into" is a sdkType.Object that must have
+// "Kind" and "APIVersion" specified in its "TypeMeta" field.
+// Returns an error if no watch of the objects' kind has the index, see WithIndexers().
+func ListByIndex(namespace string, into Object, indexName, indexedValue string) error {
+	return listByIndex("", namespace, into, indexName, indexedValue)
+}
+
+// ListByIndexWithContext is like ListByIndex, but reads the cache of the watches of the
+// cluster set by ContextWithCluster, if any, see WithCluster.
+func ListByIndexWithContext(ctx context.Context, namespace string, into Object, indexName, indexedValue string) error {
+	cluster, _ := ctx.Value(clusterKey{}).(string)
+	return listByIndex(cluster, namespace, into, indexName, indexedValue)
+}
+
+func listByIndex(cluster, namespace string, into Object, indexName, indexedValue string) error {
+	gvk := into.GetObjectKind().GroupVersionKind()
+	apiVersion, kind := gvk.ToAPIVersionAndKind()
+	l, err := indexedList(cluster, apiVersion, kind, namespace, indexName, indexedValue)
+	if err != nil {
+		return err
+	}
+	if err := k8sutil.RuntimeObjectIntoRuntimeObject(l, into); err != nil {
+		return fmt.Errorf("failed to unmarshal the retrieved data: %v", err)
+	}
+	return nil
+}
+
+func (c *dynamicClient) liveGet(apiVersion, kind, namespace, name string, o *GetOp) (*unstructured.Unstructured, error) {
+	resourceClient, _, err := c.resourceClient(c.requestContext(), apiVersion, kind, namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get resource client for (apiVersion:%s, kind:%s, ns:%s): %v", apiVersion, kind, namespace, err)
+	}
+	return resourceClient.Get(name, *o.metaGetOptions)
+}
+
+func (c *dynamicClient) liveList(apiVersion, kind, namespace string, o *ListOp) (*unstructured.UnstructuredList, error) {
+	resourceClient, _, err := c.resourceClient(c.requestContext(), apiVersion, kind, namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get resource client for (apiVersion:%s, kind:%s, ns:%s): %v", apiVersion, kind, namespace, err)
+	}
+	return resourceClient.List(*o.metaListOptions)
+}
@@ -0,0 +1,135 @@
+// Copyright 2018 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk
+
+import (
+	"context"
+	"sync"
+
+	"github.com/operator-framework/operator-sdk/pkg/k8sclient"
+	"github.com/operator-framework/operator-sdk/pkg/util/k8sutil"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+)
+
+const (
+	// defaultEventSource is the source component of events if OPERATOR_NAME is not set.
+	defaultEventSource = "operator-sdk"
+	// reasonReconcileFailed is the reason of the Warning event recorded for a key that is
+	// dropped out of the queue after the handler failed for it maxRetries times.
+	reasonReconcileFailed = "ReconcileFailed"
+)
+
+// Recorder records Kubernetes Events for objects, which are shown by `kubectl describe`.
+// The reason is a short CamelCase string that tells why the event was recorded, e.g "ScaledUp".
+type Recorder interface {
+	// Normal records an event of type Normal, e.g for a change made to the object.
+	Normal(object Object, reason, messageFmt string, args ...interface{})
+	// Warning records an event of type Warning, e.g for a failed reconcile.
+	Warning(object Object, reason, messageFmt string, args ...interface{})
+}
+
+type eventRecorder struct {
+	recorder record.EventRecorder
+}
+
+// NewRecorder returns a Recorder that records events with the given record.EventRecorder.
+// Handler unit tests can pass a record.FakeRecorder to check the recorded events.
+func NewRecorder(recorder record.EventRecorder) Recorder {
+	return &eventRecorder{recorder: recorder}
+}
+
+func (r *eventRecorder) Normal(object Object, reason, messageFmt string, args ...interface{}) {
+	r.recorder.Eventf(object, corev1.EventTypeNormal, reason, messageFmt, args...)
+}
+
+func (r *eventRecorder) Warning(object Object, reason, messageFmt string, args ...interface{}) {
+	r.recorder.Eventf(object, corev1.EventTypeWarning, reason, messageFmt, args...)
+}
+
+var (
+	eventSourceOnce sync.Once
+	eventSource     corev1.EventSource
+)
+
+// getEventSource returns the source of the recorded events, the name of the operator.
+func getEventSource() corev1.EventSource {
+	eventSourceOnce.Do(func() {
+		source, err := k8sutil.GetOperatorName()
+		if err != nil {
+			logrus.Warnf("Recording events with source (%s): %v", defaultEventSource, err)
+			source = defaultEventSource
+		}
+		eventSource = corev1.EventSource{Component: source}
+	})
+	return eventSource
+}
+
+// getDefaultRecorder returns a Recorder that sends events to the API server
+// with the EventBroadcaster of the default k8sclient.Factory, see k8sclient.SetDefaultFactory.
+// Similar events are aggregated into one event with a count, and an object that
+// gets too many events is rate limited, so a failing handler cannot flood the API server.
+// Events are logged and dropped if the default Factory can't be created.
+func getDefaultRecorder() Recorder {
+	f, err := k8sclient.DefaultFactory()
+	if err != nil {
+		logrus.Errorf("failed to record events: %v", err)
+		return discardRecorder{}
+	}
+	return NewRecorder(f.EventBroadcaster().NewRecorder(scheme.Scheme, getEventSource()))
+}
+
+// clusterRecorder returns a Recorder that sends events to the API server of the
+// cluster of the given name, see k8sclient.ClusterFactory.
+// Events for a cluster without a Factory are logged and dropped.
+func clusterRecorder(cluster string) Recorder {
+	f, err := k8sclient.ClusterFactory(cluster)
+	if err != nil {
+		logrus.Errorf("failed to record events for cluster (%s): %v", cluster, err)
+		return discardRecorder{}
+	}
+	return NewRecorder(f.EventBroadcaster().NewRecorder(scheme.Scheme, getEventSource()))
+}
+
+// discardRecorder drops all events.
+type discardRecorder struct{}
+
+func (discardRecorder) Normal(object Object, reason, messageFmt string, args ...interface{}) {}
+
+func (discardRecorder) Warning(object Object, reason, messageFmt string, args ...interface{}) {}
+
+type recorderKey struct{}
+
+// ContextWithRecorder returns a copy of ctx that carries the recorder.
+// Handler unit tests can pass it to Handle to make the handler use a fake Recorder.
+func ContextWithRecorder(ctx context.Context, recorder Recorder) context.Context {
+	return context.WithValue(ctx, recorderKey{}, recorder)
+}
+
+// RecorderFromContext returns the Recorder carried by ctx, or the Recorder that sends
+// events to the API server if ctx carries none. The events are sent to the cluster set
+// by ContextWithCluster, if any.
+func RecorderFromContext(ctx context.Context) Recorder {
+	if recorder, ok := ctx.Value(recorderKey{}).(Recorder); ok {
+		return recorder
+	}
+	if cluster, ok := ctx.Value(clusterKey{}).(string); ok && cluster != "" {
+		return clusterRecorder(cluster)
+	}
+	return getDefaultRecorder()
+}
@@ -0,0 +1,72 @@
+// Copyright 2018 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/operator-framework/operator-sdk/pkg/k8sclient"
+	"github.com/operator-framework/operator-sdk/pkg/util/k8sutil"
+
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+)
+
+func TestRecordDropped(t *testing.T) {
+	fake := record.NewFakeRecorder(10)
+	i := newTestInformer(t, "", newTestPod("ns1", "a", "web", "uid-1"))
+	i.context = ContextWithRecorder(context.TODO(), NewRecorder(fake))
+	i.queue = workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	defer i.queue.ShutDown()
+	i.tombstones = newTombstoneStore(defaultTombstoneTTL, defaultMaxTombstones, nil)
+
+	i.handleResult(Result{}, errors.New("boom"), "ns1/a")
+	i.handleResult(Result{}, errors.New("boom"), "ns1/deleted")
+
+	if len(fake.Events) != 1 {
+		t.Fatalf("expected 1 event; got: %d", len(fake.Events))
+	}
+	event := <-fake.Events
+	if !strings.HasPrefix(event, "Warning ReconcileFailed") || !strings.Contains(event, "boom") {
+		t.Errorf("expected warning event for the error; got: %s", event)
+	}
+}
+
+func TestRecorderFromContextForCluster(t *testing.T) {
+	fake := NewRecorder(record.NewFakeRecorder(1))
+	ctx := ContextWithCluster(context.TODO(), "missing")
+	if r := RecorderFromContext(ContextWithRecorder(ctx, fake)); r != fake {
+		t.Errorf("expected the recorder carried by the context; got: %v", r)
+	}
+	// The events for a cluster without a factory are dropped rather than sent to the default cluster.
+	if r := RecorderFromContext(ctx); r != (discardRecorder{}) {
+		t.Errorf("expected the events for the cluster to be dropped; got: %v", r)
+	}
+}
+
+func TestRecorderFromContextWithoutConfig(t *testing.T) {
+	defer os.Unsetenv(k8sutil.KubeConfigEnvVar)
+	os.Setenv(k8sutil.KubeConfigEnvVar, "/nonexistent/kubeconfig")
+	k8sclient.SetDefaultFactory(nil)
+
+	// The events are dropped rather than crashing the handler if the default factory can't be created.
+	if r := RecorderFromContext(context.TODO()); r != (discardRecorder{}) {
+		t.Errorf("expected the events to be dropped; got: %v", r)
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2018 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk
+
+import "time"
+
+// runOp wraps all the options for Run().
+type runOp struct {
+	leaderElection  bool
+	shutdownTimeout time.Duration
+	concurrencyMode ConcurrencyMode
+}
+
+// newRunOp creates a new default runOp
+func newRunOp() *runOp {
+	op := &runOp{}
+	op.setDefaults()
+	return op
+}
+
+func (op *runOp) applyOpts(opts []runOption) {
+	for _, opt := range opts {
+		opt(op)
+	}
+}
+
+func (op *runOp) setDefaults() {
+	if op.concurrencyMode == "" {
+		op.concurrencyMode = ConcurrencyParallel
+	}
+}
+
+// runOption configures runOp.
+type runOption func(*runOp)
+
+// WithLeaderElection makes Run() wait until the operator pod becomes the leader
+// before watching any resources. The lock is a ConfigMap named "<OPERATOR_NAME>-lock"
+// in the operator pod's namespace. See pkg/leader for details.
+func WithLeaderElection() runOption {
+	return func(op *runOp) {
+		op.leaderElection = true
+	}
+}
+
+// WithGracefulShutdown makes Run() shut down gracefully once its context is done:
+// the informers stop handling new events, and Run waits up to timeout for
+// in-flight calls to the handler to return before it returns itself.
+// Handlers keep a live context until the timeout expires.
+// The keys that were still queued are logged.
+func WithGracefulShutdown(timeout time.Duration) runOption {
+	return func(op *runOp) {
+		op.shutdownTimeout = timeout
+	}
+}
+
+// WithConcurrencyMode sets which calls into the handler may run concurrently,
+// e.g ConcurrencySerial for a handler that is not safe for concurrent use.
+// The default is ConcurrencyParallel.
+func WithConcurrencyMode(mode ConcurrencyMode) runOption {
+	return func(op *runOp) {
+		op.concurrencyMode = mode
+	}
+}
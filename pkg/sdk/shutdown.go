@@ -0,0 +1,78 @@
+// Copyright 2018 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// runAndDrain runs all informers until ctx is done, then waits up to timeout
+// for the in-flight handlers to return. callLock serializes the calls into the handler.
+func runAndDrain(ctx context.Context, timeout time.Duration, callLock handlerLock) {
+	// Handlers must not see ctx cancelled while they are being drained.
+	handlerCtx, cancelHandlers := context.WithCancel(detachedContext{parent: ctx})
+	defer cancelHandlers()
+
+	var wg sync.WaitGroup
+	startInformers(&runState{ctx: ctx, handlerCtx: handlerCtx, drain: true, callLock: callLock, wg: &wg})
+	<-ctx.Done()
+	stopStartingInformers()
+
+	logrus.Infof("shutting down, waiting up to %v for in-flight events to be handled", timeout)
+	drained := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+		logrus.Info("all in-flight events have been handled")
+	case <-time.After(timeout):
+		logrus.Warnf("timed out after %v waiting for in-flight events to be handled, cancelling them", timeout)
+	}
+
+	informersMu.RLock()
+	defer informersMu.RUnlock()
+	for _, inf := range informers {
+		if keys := inf.pendingKeys(); len(keys) > 0 {
+			logrus.Warnf("%d keys for %s were still queued at shutdown: %v", len(keys), inf.resourcePluralName, keys)
+		}
+	}
+}
+
+// detachedContext carries the values of its parent, but not its deadline or cancellation.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
+
+func (c detachedContext) Value(key interface{}) interface{} {
+	return c.parent.Value(key)
+}
@@ -0,0 +1,127 @@
+// Copyright 2018 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRunAndDrain(t *testing.T) {
+	type Scenario struct {
+		name string
+		// waitForCancel makes the handler of "a" block until its context is canceled.
+		waitForCancel bool
+		result        Result
+		timeout       time.Duration
+		// expectedCtxErr is the error of the handler's context once the handler of "a" returns.
+		expectedCtxErr error
+		expectedLog    string
+		expectedKeys   []string
+	}
+
+	scenarios := []Scenario{
+		{
+			name:         "in-flight event completes",
+			timeout:      5 * time.Second,
+			expectedLog:  "all in-flight events have been handled",
+			expectedKeys: []string{"ns1/b"},
+		},
+		{
+			name:         "requeued key is pending",
+			result:       Result{Requeue: true},
+			timeout:      5 * time.Second,
+			expectedLog:  "2 keys for pods were still queued at shutdown",
+			expectedKeys: []string{"ns1/a", "ns1/b"},
+		},
+		{
+			name:           "timeout cancels the handler",
+			waitForCancel:  true,
+			timeout:        50 * time.Millisecond,
+			expectedCtxErr: context.Canceled,
+			expectedLog:    "timed out after 50ms waiting for in-flight events to be handled",
+			expectedKeys:   []string{"ns1/b"},
+		},
+	}
+
+	var log bytes.Buffer
+	logrus.SetOutput(&log)
+	defer logrus.SetOutput(os.Stderr)
+	defer func() {
+		RegisteredHandler = nil
+		informers = nil
+	}()
+
+	for _, s := range scenarios {
+		log.Reset()
+		entered := make(chan struct{}, 1)
+		gate := make(chan struct{})
+		ctxErr := make(chan error, 1)
+		mux := NewMux()
+		mux.HandleResultFallback(ResultHandlerFunc(func(ctx context.Context, event Event) (Result, error) {
+			if event.Object.(metav1.Object).GetName() != "a" {
+				return Result{}, nil
+			}
+			entered <- struct{}{}
+			if s.waitForCancel {
+				<-ctx.Done()
+			} else {
+				<-gate
+			}
+			ctxErr <- ctx.Err()
+			return s.result, nil
+		}))
+		RegisteredHandler = mux
+		informers = nil
+		i := newRunnableTestInformer(newTestPod("ns1", "a", "web", "uid-1"), newTestPod("ns1", "b", "web", "uid-1"))
+		addWatch(&WatchHandle{informers: []*informer{i}})
+
+		ctx, cancel := context.WithCancel(context.TODO())
+		drained := make(chan struct{})
+		go func() {
+			runAndDrain(ctx, s.timeout, noLock{})
+			close(drained)
+		}()
+		<-entered
+		cancel()
+		for !i.isStopping() {
+			time.Sleep(time.Millisecond)
+		}
+		close(gate)
+		select {
+		case <-drained:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("test %s failed, expected the drain to return", s.name)
+		}
+
+		if err := <-ctxErr; err != s.expectedCtxErr {
+			t.Errorf("test %s failed, expected handler context error: %v; got: %v", s.name, s.expectedCtxErr, err)
+		}
+		if !strings.Contains(log.String(), s.expectedLog) {
+			t.Errorf("test %s failed, expected log: %q; got: %q", s.name, s.expectedLog, log.String())
+		}
+		if keys := i.pendingKeys(); !reflect.DeepEqual(keys, s.expectedKeys) {
+			t.Errorf("test %s failed, expected pending keys: %v; got: %v", s.name, s.expectedKeys, keys)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright 2018 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// tombstoneStore keeps the last known state of deleted objects until their
+// delete event is handled. Tombstones expire after ttl, and the oldest ones
+// are evicted once the store holds maxSize of them.
+// It is safe for concurrent use.
+type tombstoneStore struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	maxSize int
+	// order holds the tombstones from the oldest to the newest.
+	order   *list.List
+	entries map[string]*list.Element
+	// size tracks the number of tombstones in the store.
+	size prom.Gauge
+	now  func() time.Time
+}
+
+type tombstone struct {
+	key     string
+	obj     *unstructured.Unstructured
+	expires time.Time
+}
+
+func newTombstoneStore(ttl time.Duration, maxSize int, size prom.Gauge) *tombstoneStore {
+	return &tombstoneStore{
+		ttl:     ttl,
+		maxSize: maxSize,
+		order:   list.New(),
+		entries: map[string]*list.Element{},
+		size:    size,
+		now:     time.Now,
+	}
+}
+
+// add stores the last known state of the deleted object with the given key.
+func (s *tombstoneStore) add(key string, obj *unstructured.Unstructured) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expire()
+	if e, ok := s.entries[key]; ok {
+		s.removeElement(e)
+	}
+	for s.order.Len() > 0 && s.order.Len() >= s.maxSize {
+		oldest := s.order.Front()
+		logrus.Warnf("Evicting last known state of deleted object (%s): too many deleted objects are queued", oldest.Value.(*tombstone).key)
+		s.removeElement(oldest)
+	}
+	s.entries[key] = s.order.PushBack(&tombstone{key: key, obj: obj, expires: s.now().Add(s.ttl)})
+	s.size.Inc()
+}
+
+// get returns the last known state of the deleted object with the given key.
+func (s *tombstoneStore) get(key string) (*unstructured.Unstructured, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expire()
+	e, ok := s.entries[key]
+	if !ok {
+		return nil, false
+	}
+	return e.Value.(*tombstone).obj, true
+}
+
+// remove deletes the last known state of the deleted object with the given key, if any.
+func (s *tombstoneStore) remove(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.entries[key]; ok {
+		s.removeElement(e)
+	}
+}
+
+// expire removes the expired tombstones. s.mu must be held.
+func (s *tombstoneStore) expire() {
+	now := s.now()
+	for e := s.order.Front(); e != nil && !now.Before(e.Value.(*tombstone).expires); e = s.order.Front() {
+		logrus.Warnf("Last known state of deleted object (%s) expired before it was handled", e.Value.(*tombstone).key)
+		s.removeElement(e)
+	}
+}
+
+func (s *tombstoneStore) removeElement(e *list.Element) {
+	s.order.Remove(e)
+	delete(s.entries, e.Value.(*tombstone).key)
+	s.size.Dec()
+}
@@ -0,0 +1,98 @@
+// Copyright 2018 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk
+
+import (
+	"testing"
+	"time"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+)
+
+func TestTombstoneStore(t *testing.T) {
+	now := time.Now()
+	s := newTombstoneStore(time.Minute, 2, prom.NewGauge(prom.GaugeOpts{Name: "test_tombstones"}))
+	s.now = func() time.Time { return now }
+
+	s.add("ns1/a", newTestPod("ns1", "a", "web", "uid-1"))
+	now = now.Add(30 * time.Second)
+	s.add("ns1/b", newTestPod("ns1", "b", "web", "uid-1"))
+	s.add("ns1/c", newTestPod("ns1", "c", "web", "uid-1"))
+
+	// The oldest tombstone is evicted once the store is full.
+	if _, ok := s.get("ns1/a"); ok {
+		t.Errorf("expected tombstone ns1/a to be evicted")
+	}
+	if obj, ok := s.get("ns1/b"); !ok || obj.GetName() != "b" {
+		t.Errorf("expected tombstone ns1/b; got: %v, %v", obj, ok)
+	}
+
+	s.remove("ns1/b")
+	if _, ok := s.get("ns1/b"); ok {
+		t.Errorf("expected tombstone ns1/b to be removed")
+	}
+
+	// Tombstones expire after the TTL.
+	now = now.Add(time.Minute)
+	if _, ok := s.get("ns1/c"); ok {
+		t.Errorf("expected tombstone ns1/c to expire")
+	}
+	if n := s.order.Len(); n != 0 {
+		t.Errorf("expected empty store; got: %d tombstones", n)
+	}
+}
+
+func TestWithTombstones(t *testing.T) {
+	type Scenario struct {
+		name            string
+		ttl             time.Duration
+		maxSize         int
+		expectedTTL     time.Duration
+		expectedMaxSize int
+	}
+
+	tests := []Scenario{
+		Scenario{
+			name:            "Custom ttl and size",
+			ttl:             time.Minute,
+			maxSize:         10,
+			expectedTTL:     time.Minute,
+			expectedMaxSize: 10,
+		},
+		Scenario{
+			name:            "Zero values keep the defaults",
+			expectedTTL:     defaultTombstoneTTL,
+			expectedMaxSize: defaultMaxTombstones,
+		},
+		Scenario{
+			name:            "Negative values keep the defaults",
+			ttl:             -time.Minute,
+			maxSize:         -1,
+			expectedTTL:     defaultTombstoneTTL,
+			expectedMaxSize: defaultMaxTombstones,
+		},
+	}
+
+	for _, test := range tests {
+		o := newWatchOp()
+		o.applyOpts([]watchOption{WithTombstones(test.ttl, test.maxSize)})
+		if o.tombstoneTTL != test.expectedTTL {
+			t.Errorf("test %s failed, expected ttl: %v; got: %v", test.name, test.expectedTTL, o.tombstoneTTL)
+		}
+		if o.maxTombstones != test.expectedMaxSize {
+			t.Errorf("test %s failed, expected max size: %d; got: %d", test.name, test.expectedMaxSize, o.maxTombstones)
+		}
+	}
+}
@@ -20,10 +20,33 @@ import "k8s.io/apimachinery/pkg/runtime"
 // of all resources that the user can watch.
 type Object runtime.Object
 
+// EventType is the type of change that triggered an Event.
+type EventType string
+
+const (
+	// EventTypeAdd is the type of an Event for a new object.
+	EventTypeAdd EventType = "add"
+	// EventTypeUpdate is the type of an Event for a changed object.
+	EventTypeUpdate EventType = "update"
+	// EventTypeDelete is the type of an Event for a deleted object.
+	EventTypeDelete EventType = "delete"
+	// EventTypeResync is the type of an Event for an object that did not change,
+	// e.g on a periodic resync, a requeue, or a change to an owned object.
+	EventTypeResync EventType = "resync"
+)
+
 // Event is triggered when some change has happened on the watched resources.
 // If created or updated, Object would be the current state and Deleted=false.
 // If deleted, Object would be the last known state and Deleted=true.
+// Changes to an object are merged until the handler is called for it, so an object
+// that was created and then updated is handled once, with Type EventTypeAdd.
 type Event struct {
-	Object  Object
-	Deleted bool
+	Type   EventType
+	Object Object
+	// OldObject is the state of the object before the first of the merged updates
+	// if Type is EventTypeUpdate, and nil otherwise.
+	OldObject Object
+	Deleted   bool
+	// Cluster is the name of the cluster of the watch if set with WithCluster, and empty otherwise.
+	Cluster string
 }
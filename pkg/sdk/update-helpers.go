@@ -0,0 +1,129 @@
+// Copyright 2018 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/operator-framework/operator-sdk/pkg/util/k8sutil"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/util/retry"
+)
+
+// MutateFunc changes the object to its desired state, e.g sets fields of the spec.
+// It may be called several times, each time with the latest state of the object
+// from the server, and must not change the name or namespace of the object.
+type MutateFunc func(object Object) error
+
+// OperationResult tells what CreateOrUpdate did.
+type OperationResult string
+
+const (
+	// OperationResultNone means that the object already was in its desired state.
+	OperationResultNone OperationResult = "unchanged"
+	// OperationResultCreated means that the object was created.
+	OperationResultCreated OperationResult = "created"
+	// OperationResultUpdated means that the object was updated.
+	OperationResultUpdated OperationResult = "updated"
+)
+
+// UpdateWithRetry gets the latest state of the object from the server into the arg "object",
+// applies mutate to it and updates it. If the update fails with a Conflict error because the
+// object was changed in the meantime, it starts over with a short backoff, up to 5 times.
+// The arg "object" must have the TypeMeta(Kind, APIVersion) and the ObjectMeta(Name, Namespace)
+// of the object set, and holds the result from the server on return.
+// The Client to use is taken from ctx, see ClientFromContext.
+func UpdateWithRetry(ctx context.Context, object Object, mutate MutateFunc) error {
+	client := ClientFromContext(ctx)
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := client.Get(object, WithLiveRead()); err != nil {
+			return err
+		}
+		if err := mutateObject(object, mutate); err != nil {
+			return err
+		}
+		return client.Update(object)
+	})
+}
+
+// CreateOrUpdate gets the latest state of the object from the server into the arg "object"
+// and applies mutate to it. If the object does not exist, it is created from the arg "object"
+// with mutate applied. If the object exists and mutate changed it, it is updated, retrying on
+// Conflict errors like UpdateWithRetry. Otherwise nothing is written.
+// The arg "object" must have the TypeMeta(Kind, APIVersion) and the ObjectMeta(Name, Namespace)
+// of the object set, and holds the result from the server on return.
+// The Client to use is taken from ctx, see ClientFromContext.
+func CreateOrUpdate(ctx context.Context, object Object, mutate MutateFunc) (OperationResult, error) {
+	client := ClientFromContext(ctx)
+	result := OperationResultNone
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		err := client.Get(object, WithLiveRead())
+		if apierrors.IsNotFound(err) {
+			if err := mutateObject(object, mutate); err != nil {
+				return err
+			}
+			if err := client.Create(object); err != nil {
+				return err
+			}
+			result = OperationResultCreated
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		existing := object.DeepCopyObject()
+		if err := mutateObject(object, mutate); err != nil {
+			return err
+		}
+		if equality.Semantic.DeepEqual(existing, object) {
+			return nil
+		}
+		if err := client.Update(object); err != nil {
+			return err
+		}
+		result = OperationResultUpdated
+		return nil
+	})
+	return result, err
+}
+
+// mutateObject applies mutate to the object, and makes sure that it is still the same object.
+func mutateObject(object Object, mutate MutateFunc) error {
+	name, namespace, err := k8sutil.GetNameAndNamespace(object)
+	if err != nil {
+		return err
+	}
+	if err := mutate(object); err != nil {
+		return err
+	}
+	newName, newNamespace, err := k8sutil.GetNameAndNamespace(object)
+	if err != nil {
+		return err
+	}
+	if newName != name || newNamespace != namespace {
+		return fmt.Errorf("mutate must not change the name or namespace of the object (%s/%s)", namespace, name)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+// Copyright 2018 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestUpdateWithRetry(t *testing.T) {
+	client := NewFakeClient(newTypedPod("a", "web"))
+	ctx := ContextWithClient(context.TODO(), client)
+
+	calls := 0
+	pod := newTypedPod("a", "")
+	err := UpdateWithRetry(ctx, pod, func(object Object) error {
+		calls++
+		if calls == 1 {
+			// Another writer updates the pod between the read and the update.
+			other := newTypedPod("a", "")
+			if err := client.Get(other); err != nil {
+				return err
+			}
+			other.Labels["tier"] = "backend"
+			if err := client.Update(other); err != nil {
+				return err
+			}
+		}
+		object.(*corev1.Pod).Labels["app"] = "db"
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to update pod: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("expected mutate calls: 2; got: %d", calls)
+	}
+	if pod.Labels["app"] != "db" || pod.Labels["tier"] != "backend" {
+		t.Errorf("expected both updates; got: %v", pod.Labels)
+	}
+}
+
+func TestCreateOrUpdate(t *testing.T) {
+	ctx := ContextWithClient(context.TODO(), NewFakeClient())
+	setApp := func(app string) MutateFunc {
+		return func(object Object) error {
+			object.(*corev1.Pod).Labels = map[string]string{"app": app}
+			return nil
+		}
+	}
+
+	type Scenario struct {
+		name     string
+		app      string
+		expected OperationResult
+	}
+
+	tests := []Scenario{
+		Scenario{
+			name:     "Missing object",
+			app:      "web",
+			expected: OperationResultCreated,
+		},
+		Scenario{
+			name:     "Unchanged object",
+			app:      "web",
+			expected: OperationResultNone,
+		},
+		Scenario{
+			name:     "Changed object",
+			app:      "db",
+			expected: OperationResultUpdated,
+		},
+	}
+
+	for _, test := range tests {
+		pod := newTypedPod("a", "")
+		result, err := CreateOrUpdate(ctx, pod, setApp(test.app))
+		if err != nil {
+			t.Errorf("test %s failed: %v", test.name, err)
+			continue
+		}
+		if result != test.expected {
+			t.Errorf("test %s failed, expected result: %s; got: %s", test.name, test.expected, result)
+		}
+		if pod.Labels["app"] != test.app {
+			t.Errorf("test %s failed, expected app label: %s; got: %v", test.name, test.app, pod.Labels)
+		}
+	}
+
+	rename := func(object Object) error {
+		object.(*corev1.Pod).Name = "b"
+		return nil
+	}
+	if _, err := CreateOrUpdate(ctx, newTypedPod("a", ""), rename); err == nil {
+		t.Error("expected an error for a renamed object")
+	}
+}
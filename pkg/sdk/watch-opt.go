@@ -14,10 +14,56 @@
 
 package sdk
 
+import (
+	"time"
+
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+)
+
+const (
+	// defaultMaxRetries is the number of times a key is retried before it is dropped out of the queue.
+	// With the default rate-limiter in use (5ms*2^(maxRetries-1)) the following numbers represent the times
+	// a key is going to be requeued:
+	//
+	// 5ms, 10ms, 20ms, 40ms, 80ms, 160ms, 320ms, 640ms, 1.3s, 2.6s, 5.1s, 10.2s, 20.4s, 41s, 82s
+	defaultMaxRetries = 15
+
+	// defaultTombstoneTTL is how long the last known state of a deleted object is kept
+	// for its delete event, e.g while the handler keeps failing for it.
+	defaultTombstoneTTL = time.Hour
+	// defaultMaxTombstones is the number of deleted objects whose last known state is kept.
+	defaultMaxTombstones = 10000
+
+	// UnlimitedRetries makes WithMaxRetries() retry a failed key until it succeeds.
+	UnlimitedRetries = -1
+)
+
+// DeadLetterFunc is called with the key of an object and the last error
+// from the handler when the key is dropped out of the queue after its last retry.
+type DeadLetterFunc func(key string, err error)
+
 // WatchOp wraps all the options for Watch().
 type watchOp struct {
 	numWorkers    int
 	labelSelector string
+	fieldSelector string
+	// namespaceSelector filters events by the labels of the object's namespace.
+	namespaceSelector string
+	predicates        []Predicate
+	tombstoneTTL      time.Duration
+	maxTombstones     int
+	reconcileTimeout  time.Duration
+	rateLimiter       workqueue.RateLimiter
+	maxRetries        int
+	deadLetterFunc    DeadLetterFunc
+	indexers          cache.Indexers
+	ownerAPIVersion   string
+	ownerKind         string
+	// waitForKind is the interval to retry watching a kind that can't be watched yet at.
+	waitForKind time.Duration
+	// cluster is the name of the cluster to watch, empty for the default cluster.
+	cluster string
 }
 
 // NewWatchOp create a new deafult WatchOp
@@ -37,6 +83,18 @@ func (op *watchOp) setDefaults() {
 	if op.numWorkers == 0 {
 		op.numWorkers = 1
 	}
+	if op.rateLimiter == nil {
+		op.rateLimiter = workqueue.DefaultControllerRateLimiter()
+	}
+	if op.maxRetries == 0 {
+		op.maxRetries = defaultMaxRetries
+	}
+	if op.tombstoneTTL == 0 {
+		op.tombstoneTTL = defaultTombstoneTTL
+	}
+	if op.maxTombstones == 0 {
+		op.maxTombstones = defaultMaxTombstones
+	}
 }
 
 // WatchOption configures WatchOp.
@@ -54,3 +112,134 @@ func WithLabelSelector(labelSelector string) watchOption {
 		op.labelSelector = labelSelector
 	}
 }
+
+// WithFieldSelector sets the field selector for the Watch() operation, e.g
+// "status.phase=Running" for pods. Only the fields supported by the API server
+// for the resource can be selected.
+func WithFieldSelector(fieldSelector string) watchOption {
+	return func(op *watchOp) {
+		op.fieldSelector = fieldSelector
+	}
+}
+
+// WithNamespaceLabelSelector filters the events of the Watch() operation to the
+// objects in namespaces whose labels match the label selector, e.g "team=payments".
+// When a namespace starts to match, its objects are handled as if they had changed.
+// Cluster-scoped objects are not filtered. The operator must be allowed to list
+// and watch namespaces, which the Watch() operation does once for all its namespaces.
+func WithNamespaceLabelSelector(labelSelector string) watchOption {
+	return func(op *watchOp) {
+		op.namespaceSelector = labelSelector
+	}
+}
+
+// WithPredicates sets the predicates that update events of the Watch() operation
+// must pass to be handled. An update event is dropped if any predicate returns false.
+// Add and delete events are always handled. See GenerationChanged for an example.
+func WithPredicates(predicates ...Predicate) watchOption {
+	return func(op *watchOp) {
+		op.predicates = append(op.predicates, predicates...)
+	}
+}
+
+// WithRateLimiter sets the rate limiter for requeuing failed keys for the Watch() operation.
+// The default is workqueue.DefaultControllerRateLimiter(). For example, to back off
+// exponentially from 1s up to 10m:
+//
+//	sdk.WithRateLimiter(workqueue.NewItemExponentialFailureRateLimiter(time.Second, 10*time.Minute))
+func WithRateLimiter(rateLimiter workqueue.RateLimiter) watchOption {
+	return func(op *watchOp) {
+		op.rateLimiter = rateLimiter
+	}
+}
+
+// WithMaxRetries sets the number of times a failed key is retried before it is
+// dropped out of the queue for the Watch() operation. The default is 15.
+// UnlimitedRetries never drops a failed key.
+func WithMaxRetries(maxRetries int) watchOption {
+	return func(op *watchOp) {
+		op.maxRetries = maxRetries
+	}
+}
+
+// WithDeadLetterFunc sets the function called when a failed key is dropped
+// out of the queue after its last retry for the Watch() operation.
+func WithDeadLetterFunc(deadLetterFunc DeadLetterFunc) watchOption {
+	return func(op *watchOp) {
+		op.deadLetterFunc = deadLetterFunc
+	}
+}
+
+// WithIndexers adds the indexers to the cache of the Watch() operation.
+// The indexed objects can be listed with ListByIndex(). The indexers are
+// called with *unstructured.Unstructured objects, see OwnerUIDIndexFunc and
+// LabelIndexFunc for ready made ones.
+func WithIndexers(indexers cache.Indexers) watchOption {
+	return func(op *watchOp) {
+		if op.indexers == nil {
+			op.indexers = cache.Indexers{}
+		}
+		for name, indexFunc := range indexers {
+			op.indexers[name] = indexFunc
+		}
+	}
+}
+
+// WithEnqueueOwner makes the Watch() operation deliver events for the owners of the
+// watched objects instead of the objects themselves. An event on a watched object is
+// mapped through its OwnerReferences to the owners of the given apiVersion and kind,
+// which are then handled as if they had changed. The owner kind must be watched as well.
+func WithEnqueueOwner(apiVersion, kind string) watchOption {
+	return func(op *watchOp) {
+		op.ownerAPIVersion = apiVersion
+		op.ownerKind = kind
+	}
+}
+
+// WithTombstones sets how long, and for how many deleted objects, the Watch() operation
+// keeps the last known state of a deleted object until its delete event is handled.
+// The defaults are 1h and 10000, which are kept for a ttl or maxSize of 0 or less.
+// Once a delete event can't be handled anymore, it is dropped with an error.
+func WithTombstones(ttl time.Duration, maxSize int) watchOption {
+	return func(op *watchOp) {
+		if ttl > 0 {
+			op.tombstoneTTL = ttl
+		}
+		if maxSize > 0 {
+			op.maxTombstones = maxSize
+		}
+	}
+}
+
+// WithReconcileTimeout sets the deadline of the context passed to the handler for each event
+// of the Watch() operation. The requests of the Client from ClientFromContext(ctx), and of the
+// package-level functions like CreateWithContext, are canceled once it expires. An event whose
+// handler returns an error after the deadline is retried like any failed event.
+// The default is no deadline.
+func WithReconcileTimeout(timeout time.Duration) watchOption {
+	return func(op *watchOp) {
+		op.reconcileTimeout = timeout
+	}
+}
+
+// WithWaitForKind makes the Watch() operation wait until the kind is known to the API server,
+// e.g until its CRD is installed, instead of panicking. Watch() returns right away, and the watch
+// is started by Run once the kind is found, retrying every interval. The operator is not ready
+// meanwhile, see AddReadyCheck. Other errors still make Watch() panic.
+// A newly installed kind is looked up again at most once per k8sclient.WithMinRefreshInterval.
+func WithWaitForKind(interval time.Duration) watchOption {
+	return func(op *watchOp) {
+		op.waitForKind = interval
+	}
+}
+
+// WithCluster makes the Watch() operation watch the kind in the cluster of the given name,
+// using the client factory set for the name with k8sclient.SetClusterFactory or
+// k8sclient.SetClusterFactoryFromSecret, instead of the cluster the operator runs in.
+// The events of the watch have their Cluster set to the name, and the handler's context targets
+// the cluster, so that ClientFromContext and RecorderFromContext act on it, see ContextWithCluster.
+func WithCluster(name string) watchOption {
+	return func(op *watchOp) {
+		op.cluster = name
+	}
+}
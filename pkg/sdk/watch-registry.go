@@ -0,0 +1,237 @@
+// Copyright 2018 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/dynamic"
+)
+
+// runState is the state of Run that informers are started with, including
+// those of the watches added while Run runs.
+type runState struct {
+	ctx        context.Context
+	handlerCtx context.Context
+	drain      bool
+	// callLock serializes the calls into the handler for the concurrency mode set by Run.
+	callLock handlerLock
+	// wg tracks the started informers until they stopped.
+	wg *sync.WaitGroup
+}
+
+var (
+	// running is the state of Run while it starts informers, guarded by informersMu.
+	running *runState
+	// waiters are the watches still waiting for their kind, guarded by informersMu.
+	waiters []*kindWaiter
+)
+
+// WatchHandle is a watch added by AddWatch.
+type WatchHandle struct {
+	informers []*informer
+	// waiters wait for the kind of the watch in a namespace, see WithWaitForKind.
+	waiters []*kindWaiter
+}
+
+// kindWaiter waits for the kind of a watch to be known to the API server, and then
+// adds the informer for it to the watch. See WithWaitForKind.
+type kindWaiter struct {
+	apiVersion        string
+	kind              string
+	namespace         string
+	interval          time.Duration
+	getResourceClient resourceClientFunc
+	newInformer       func(resourceClient dynamic.ResourceInterface, resourcePluralName string) *informer
+	watch             *WatchHandle
+	// stop stops the waiter started by Run, see startWaiter.
+	stop context.CancelFunc
+}
+
+// Stop stops the watch. Its informers stop watching, the keys left in their queues are
+// handled, and their workers stop. Stop blocks until then, or until ctx is done. In that case
+// the context of the handlers for the watch is canceled, the keys still queued are dropped
+// and ctx.Err() is returned. Get and List no longer read from the cache of the watch once
+// Stop is called. A watch that was not started by Run yet is only removed.
+// A watch whose cache is not synced yet stops right away.
+// Stop is safe for concurrent use, and does nothing for a stopped watch.
+func (w *WatchHandle) Stop(ctx context.Context) error {
+	var started []*informer
+	informersMu.Lock()
+	for _, i := range w.informers {
+		if !removeInformer(i) {
+			continue
+		}
+		atomic.StoreInt32(&i.removed, 1)
+		if i.stop != nil {
+			i.stop()
+			started = append(started, i)
+		}
+	}
+	for _, k := range w.waiters {
+		if removeWaiter(k) && k.stop != nil {
+			k.stop()
+		}
+	}
+	informersMu.Unlock()
+
+	for _, i := range started {
+		select {
+		case <-i.done:
+		case <-ctx.Done():
+			for _, i := range started {
+				atomic.StoreInt32(&i.stopping, 1)
+				i.cancelHandlers()
+			}
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// addWatch adds the informers and waiters of a watch, and starts them if Run runs.
+func addWatch(w *WatchHandle) {
+	informersMu.Lock()
+	defer informersMu.Unlock()
+	informers = append(informers, w.informers...)
+	waiters = append(waiters, w.waiters...)
+	if running != nil {
+		for _, i := range w.informers {
+			startInformer(i, running)
+		}
+		for _, k := range w.waiters {
+			startWaiter(k, running)
+		}
+	}
+}
+
+// startInformers starts the informers and waiters, and keeps starting those of the
+// watches added later until stopStartingInformers is called.
+func startInformers(s *runState) {
+	informersMu.Lock()
+	defer informersMu.Unlock()
+	running = s
+	for _, i := range informers {
+		startInformer(i, s)
+	}
+	for _, k := range waiters {
+		startWaiter(k, s)
+	}
+}
+
+// stopStartingInformers makes the watches added from now on wait for the next Run.
+func stopStartingInformers() {
+	informersMu.Lock()
+	defer informersMu.Unlock()
+	running = nil
+}
+
+// startInformer runs the informer until the context of Run is done or its watch is stopped.
+// informersMu must be held.
+func startInformer(i *informer, s *runState) {
+	ctx, stop := context.WithCancel(s.ctx)
+	handlerCtx, cancelHandlers := context.WithCancel(s.handlerCtx)
+	i.stop = stop
+	i.cancelHandlers = cancelHandlers
+	i.callLock = s.callLock
+	i.done = make(chan struct{})
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer close(i.done)
+		defer cancelHandlers()
+		i.run(ctx, handlerCtx, s.drain)
+	}()
+}
+
+// startWaiter runs the waiter until its kind is known, the context of Run is done
+// or its watch is stopped. informersMu must be held.
+func startWaiter(k *kindWaiter, s *runState) {
+	ctx, stop := context.WithCancel(s.ctx)
+	k.stop = stop
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer stop()
+		k.wait(ctx)
+	}()
+}
+
+// wait gets the resource client for the kind every interval until the kind is known to
+// the API server, and then adds the informer for it. wait returns early once ctx is done.
+func (k *kindWaiter) wait(ctx context.Context) {
+	for {
+		resourceClient, resourcePluralName, err := k.getResourceClient(ctx, k.apiVersion, k.kind, k.namespace)
+		if err == nil {
+			k.resolve(k.newInformer(resourceClient, resourcePluralName))
+			return
+		}
+		// Only a missing kind was checked by AddWatch, other errors are retried as well.
+		if meta.IsNoMatchError(err) {
+			logrus.Warnf("Waiting %v to retry watching (apiVersion:%s, kind:%s, ns:%s): %v", k.interval, k.apiVersion, k.kind, k.namespace, err)
+		} else {
+			logrus.Errorf("failed to get resource client for (apiVersion:%s, kind:%s, ns:%s), retrying in %v: %v", k.apiVersion, k.kind, k.namespace, k.interval, err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(k.interval):
+		}
+	}
+}
+
+// resolve replaces the waiter with the informer, and starts it if Run runs.
+func (k *kindWaiter) resolve(i *informer) {
+	informersMu.Lock()
+	defer informersMu.Unlock()
+	// The watch was stopped meanwhile.
+	if !removeWaiter(k) {
+		return
+	}
+	k.watch.informers = append(k.watch.informers, i)
+	informers = append(informers, i)
+	if running != nil {
+		startInformer(i, running)
+	}
+}
+
+// removeWaiter removes the waiter from waiters, and returns false if it was not there.
+// informersMu must be held.
+func removeWaiter(removed *kindWaiter) bool {
+	for n, k := range waiters {
+		if k == removed {
+			waiters = append(waiters[:n], waiters[n+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// removeInformer removes the informer from informers, and returns false if it was not there.
+// informersMu must be held.
+func removeInformer(removed *informer) bool {
+	for n, i := range informers {
+		if i == removed {
+			informers = append(informers[:n], informers[n+1:]...)
+			return true
+		}
+	}
+	return false
+}
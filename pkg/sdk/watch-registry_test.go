@@ -0,0 +1,198 @@
+// Copyright 2018 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/operator-framework/operator-sdk/pkg/sdk/internal/metrics"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+)
+
+// newRunnableTestInformer returns an informer that lists the objects and never sees a change.
+func newRunnableTestInformer(objs ...*unstructured.Unstructured) *informer {
+	i := newInformer("v1", "Pod", "pods", metav1.NamespaceAll, nil, 0, metrics.New(), newWatchOp())
+	lw := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			l := &unstructured.UnstructuredList{}
+			for _, obj := range objs {
+				l.Items = append(l.Items, *obj.DeepCopy())
+			}
+			return l, nil
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			return watch.NewFake(), nil
+		},
+	}
+	i.sharedIndexInformer = cache.NewSharedIndexInformer(lw, &unstructured.Unstructured{}, 0, cache.Indexers{})
+	i.sharedIndexInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{AddFunc: i.handleAddResourceEvent})
+	return i
+}
+
+func TestWatchHandleStop(t *testing.T) {
+	entered := make(chan string, 10)
+	handled := make(chan string, 10)
+	gate := make(chan struct{})
+	RegisteredHandler = HandlerFunc(func(ctx context.Context, event Event) error {
+		name := event.Object.(metav1.Object).GetName()
+		entered <- name
+		switch name {
+		case "gated":
+			<-gate
+		case "slow":
+			<-ctx.Done()
+		}
+		handled <- name
+		return nil
+	})
+	defer func() {
+		RegisteredHandler = nil
+		informers = nil
+	}()
+
+	fast := newRunnableTestInformer(newTestPod("ns1", "fast", "web", "uid-1"))
+	addWatch(&WatchHandle{informers: []*informer{fast}})
+	if fast.done != nil {
+		t.Fatalf("expected the watch to wait for Run")
+	}
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	startInformers(&runState{ctx: ctx, handlerCtx: ctx, callLock: noLock{}, wg: &sync.WaitGroup{}})
+	defer stopStartingInformers()
+	if name := <-handled; name != "fast" {
+		t.Errorf("expected handled object: fast; got: %s", name)
+	}
+	<-entered
+
+	// A watch added while running is started right away, and its queue is drained when it is stopped.
+	gated := newRunnableTestInformer(newTestPod("ns1", "gated", "web", "uid-1"))
+	addWatch(&WatchHandle{informers: []*informer{gated}})
+	<-entered
+	gated.queue.Add("ns1/gated")
+	stopped := make(chan error)
+	go func() {
+		stopped <- (&WatchHandle{informers: []*informer{gated}}).Stop(context.TODO())
+	}()
+	close(gate)
+	if err := <-stopped; err != nil {
+		t.Errorf("failed to stop watch: %v", err)
+	}
+	if n := len(handled); n != 2 {
+		t.Errorf("expected the queued key to be handled before the watch stopped; got: %d handled", n)
+	}
+	if cacheFor("", "v1", "Pod", "ns1") != fast {
+		t.Errorf("expected the cache of the stopped watch to be unused")
+	}
+
+	// A watch that does not drain in time has its handlers canceled.
+	slow := newRunnableTestInformer(newTestPod("ns1", "slow", "web", "uid-1"))
+	addWatch(&WatchHandle{informers: []*informer{slow}})
+	for <-entered != "slow" {
+	}
+	stopCtx, stopCancel := context.WithTimeout(context.TODO(), 50*time.Millisecond)
+	defer stopCancel()
+	if err := (&WatchHandle{informers: []*informer{slow}}).Stop(stopCtx); err != context.DeadlineExceeded {
+		t.Errorf("expected error: %v; got: %v", context.DeadlineExceeded, err)
+	}
+	select {
+	case <-slow.done:
+	case <-time.After(5 * time.Second):
+		t.Errorf("expected the watch to stop once its handlers are canceled")
+	}
+}
+
+func TestWatchWaitsForKind(t *testing.T) {
+	handled := make(chan string, 10)
+	RegisteredHandler = HandlerFunc(func(ctx context.Context, event Event) error {
+		handled <- event.Object.(metav1.Object).GetName()
+		return nil
+	})
+	defer func() {
+		RegisteredHandler = nil
+		informers = nil
+		waiters = nil
+	}()
+
+	noMatch := &meta.NoKindMatchError{GroupKind: schema.GroupKind{Group: "cache.example.com", Kind: "Memcached"}}
+	var attempts int
+	installed := &WatchHandle{}
+	installed.waiters = []*kindWaiter{{
+		apiVersion: "cache.example.com/v1alpha1",
+		kind:       "Memcached",
+		interval:   10 * time.Millisecond,
+		getResourceClient: func(ctx context.Context, apiVersion, kind, namespace string) (dynamic.ResourceInterface, string, error) {
+			// The waiter runs alone, so attempts needs no lock.
+			if attempts++; attempts < 3 {
+				return nil, "", noMatch
+			}
+			return nil, "memcacheds", nil
+		},
+		newInformer: func(resourceClient dynamic.ResourceInterface, resourcePluralName string) *informer {
+			return newRunnableTestInformer(newTestPod("ns1", "installed", "web", "uid-1"))
+		},
+		watch: installed,
+	}}
+	missing := &WatchHandle{}
+	missing.waiters = []*kindWaiter{{
+		interval: 10 * time.Millisecond,
+		getResourceClient: func(ctx context.Context, apiVersion, kind, namespace string) (dynamic.ResourceInterface, string, error) {
+			return nil, "", noMatch
+		},
+		watch: missing,
+	}}
+	addWatch(installed)
+	addWatch(missing)
+	if err := informersSynced(); err == nil {
+		t.Errorf("expected the operator not to be ready while watches wait for their kind")
+	}
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	wg := &sync.WaitGroup{}
+	startInformers(&runState{ctx: ctx, handlerCtx: ctx, callLock: noLock{}, wg: wg})
+	defer stopStartingInformers()
+	select {
+	case name := <-handled:
+		if name != "installed" {
+			t.Errorf("expected handled object: installed; got: %s", name)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected the watch to start once its kind is known")
+	}
+	if n := len(installed.informers); n != 1 {
+		t.Errorf("expected the informer to be added to the watch; got: %d informers", n)
+	}
+
+	if err := missing.Stop(context.TODO()); err != nil {
+		t.Errorf("failed to stop watch: %v", err)
+	}
+	if err := informersSynced(); err != nil {
+		t.Errorf("expected no watch to wait for its kind; got: %v", err)
+	}
+	cancel()
+	wg.Wait()
+}
@@ -20,16 +20,30 @@ const (
 	KubeConfigEnvVar = "KUBERNETES_CONFIG"
 
 	// WatchNamespaceEnvVar is the constant for env variable WATCH_NAMESPACE
-	// which is the namespace that the pod is currently running in.
+	// which is the comma separated list of namespaces the operator watches.
+	// An empty value means all namespaces.
 	WatchNamespaceEnvVar = "WATCH_NAMESPACE"
 
 	// OperatorNameEnvVar is the constant for env variable OPERATOR_NAME
 	// wich is the name of the current operator
 	OperatorNameEnvVar = "OPERATOR_NAME"
 
+	// PodNameEnvVar is the constant for env variable POD_NAME
+	// which is the name of the pod the operator is running in.
+	PodNameEnvVar = "POD_NAME"
+
 	// PrometheusMetricsPort defines the port which expose prometheus metrics
 	PrometheusMetricsPort = 60000
 
 	// PrometheusMetricsPortName define the port name used in kubernetes deployment and service
 	PrometheusMetricsPortName = "metrics"
+
+	// PrometheusMetricsPath defines the default path which expose prometheus metrics
+	PrometheusMetricsPath = "/metrics"
+
+	// HealthProbePort defines the port which serves the liveness and readiness probes
+	HealthProbePort = 60001
+
+	// HealthProbePortName defines the port name used for the probes in kubernetes deployment
+	HealthProbePortName = "health"
 )
@@ -16,8 +16,11 @@ package k8sutil
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"strings"
 
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
@@ -30,6 +33,13 @@ import (
 	cgoscheme "k8s.io/client-go/kubernetes/scheme"
 )
 
+// ErrNoNamespace indicates that a namespace could not be found for the current
+// environment, e.g because the operator is not running in a cluster.
+var ErrNoNamespace = errors.New("namespace not found for current environment")
+
+// namespaceFile is where the service account namespace is mounted in a pod.
+var namespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
 var (
 	// scheme tracks the type registry for the sdk
 	// This scheme is used to decode json data into the correct Go type based on the object's GVK
@@ -148,7 +158,9 @@ func ObjectInfo(kind, name, namespace string) string {
 	return kind + ": " + namespace + "/" + name
 }
 
-// GetWatchNamespace returns the namespace the operator should be watching for changes
+// GetWatchNamespace returns the namespace the operator should be watching for changes.
+// The value may be a comma separated list of namespaces, or empty for all namespaces,
+// and can be passed to sdk.Watch as is. See GetWatchNamespaces.
 func GetWatchNamespace() (string, error) {
 	ns, found := os.LookupEnv(WatchNamespaceEnvVar)
 	if !found {
@@ -157,6 +169,36 @@ func GetWatchNamespace() (string, error) {
 	return ns, nil
 }
 
+// GetWatchNamespaces returns the list of namespaces the operator should be watching for changes.
+// It is []string{metav1.NamespaceAll} if the operator watches all namespaces.
+func GetWatchNamespaces() ([]string, error) {
+	ns, err := GetWatchNamespace()
+	if err != nil {
+		return nil, err
+	}
+	return ParseNamespaces(ns), nil
+}
+
+// ParseNamespaces splits a comma separated list of namespaces, e.g "ns1,ns2".
+// Blank entries and duplicates are dropped. If the list has no namespaces,
+// []string{metav1.NamespaceAll} is returned.
+func ParseNamespaces(namespaces string) []string {
+	var result []string
+	seen := map[string]bool{}
+	for _, ns := range strings.Split(namespaces, ",") {
+		ns = strings.TrimSpace(ns)
+		if ns == "" || seen[ns] {
+			continue
+		}
+		seen[ns] = true
+		result = append(result, ns)
+	}
+	if len(result) == 0 {
+		return []string{metav1.NamespaceAll}
+	}
+	return result
+}
+
 // GetOperatorName return the operator name
 func GetOperatorName() (string, error) {
 	operatorName, found := os.LookupEnv(OperatorNameEnvVar)
@@ -169,13 +211,38 @@ func GetOperatorName() (string, error) {
 	return operatorName, nil
 }
 
+// GetOperatorNamespace returns the namespace the operator pod is running in.
+// Returns ErrNoNamespace if the operator is not running in a pod.
+func GetOperatorNamespace() (string, error) {
+	nsBytes, err := ioutil.ReadFile(namespaceFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", ErrNoNamespace
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(nsBytes)), nil
+}
+
+// GetPodName returns the name of the pod the operator is running in.
+func GetPodName() (string, error) {
+	podName, found := os.LookupEnv(PodNameEnvVar)
+	if !found {
+		return "", fmt.Errorf("%s must be set", PodNameEnvVar)
+	}
+	if len(podName) == 0 {
+		return "", fmt.Errorf("%s must not be empty", PodNameEnvVar)
+	}
+	return podName, nil
+}
+
 // InitOperatorService return the static service which expose operator metrics
 func InitOperatorService() (*v1.Service, error) {
 	operatorName, err := GetOperatorName()
 	if err != nil {
 		return nil, err
 	}
-	namespace, err := GetWatchNamespace()
+	namespace, err := operatorServiceNamespace()
 	if err != nil {
 		return nil, err
 	}
@@ -206,3 +273,20 @@ func InitOperatorService() (*v1.Service, error) {
 	}
 	return service, nil
 }
+
+// operatorServiceNamespace returns the namespace for the operator service: the namespace
+// the operator pod is running in or, outside a cluster, the single watched namespace.
+func operatorServiceNamespace() (string, error) {
+	ns, err := GetOperatorNamespace()
+	if err != ErrNoNamespace {
+		return ns, err
+	}
+	namespaces, err := GetWatchNamespaces()
+	if err != nil {
+		return "", err
+	}
+	if len(namespaces) != 1 || namespaces[0] == metav1.NamespaceAll {
+		return "", fmt.Errorf("%s must be a single namespace when not running in a cluster", WatchNamespaceEnvVar)
+	}
+	return namespaces[0], nil
+}
@@ -73,3 +73,36 @@ func TestGetOperatorName(t *testing.T) {
 		_ = os.Unsetenv(test.envVarKey)
 	}
 }
+
+func TestParseNamespaces(t *testing.T) {
+	type Scenario struct {
+		name           string
+		namespaces     string
+		expectedOutput []string
+	}
+
+	tests := []Scenario{
+		Scenario{
+			name:           "Single namespace",
+			namespaces:     "default",
+			expectedOutput: []string{"default"},
+		},
+		Scenario{
+			name:           "Namespace list",
+			namespaces:     "ns1, ns2,,ns1",
+			expectedOutput: []string{"ns1", "ns2"},
+		},
+		Scenario{
+			name:           "All namespaces",
+			namespaces:     "",
+			expectedOutput: []string{""},
+		},
+	}
+
+	for _, test := range tests {
+		namespaces := ParseNamespaces(test.namespaces)
+		if !reflect.DeepEqual(namespaces, test.expectedOutput) {
+			t.Errorf("test %s failed, expected ouput: %q; got: %q", test.name, test.expectedOutput, namespaces)
+		}
+	}
+}